@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Archive is yt-dlp's --download-archive file: one "<extractor> <id>" line
+// per video that finished downloading. yt-dlp appends to it; mldy reads it
+// to skip entries before they are even started.
+type Archive struct {
+	path    string
+	modTime time.Time
+	ids     map[string]bool
+}
+
+// archivePath returns ~/.config/mldy/archive.txt.
+func archivePath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "archive.txt"), nil
+}
+
+// OpenArchive reads the archive at path. A missing file is an empty
+// archive; yt-dlp creates it with the first finished download.
+func OpenArchive(path string) *Archive {
+	a := &Archive{path: path}
+	a.reload()
+	return a
+}
+
+// Path is what yt-dlp gets as --download-archive; empty when there is no
+// archive.
+func (a *Archive) Path() string {
+	if a == nil {
+		return ""
+	}
+	return a.path
+}
+
+// Contains reports whether the video with the given archive ID (see
+// archiveID) was downloaded before.
+func (a *Archive) Contains(id string) bool {
+	if a == nil || id == "" {
+		return false
+	}
+	a.reload()
+	return a.ids[id]
+}
+
+// Add appends the archive ID of a finished download that yt-dlp didn't
+// record itself, i.e. one run without --download-archive. IDs already in the
+// archive are left alone.
+func (a *Archive) Add(id string) error {
+	if a == nil || id == "" || a.Contains(id) {
+		return nil
+	}
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(id + "\n")
+	return err
+}
+
+// reload re-reads the file when yt-dlp has appended to it since.
+func (a *Archive) reload() {
+	info, err := os.Stat(a.path)
+	if err != nil {
+		a.ids = nil
+		return
+	}
+	if a.ids != nil && info.ModTime().Equal(a.modTime) {
+		return
+	}
+
+	f, err := os.Open(a.path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	ids := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			ids[line] = true
+		}
+	}
+	a.ids = ids
+	a.modTime = info.ModTime()
+}
+
+// archiveID builds the archive line yt-dlp writes for a video: the
+// lowercased extractor key and the video ID, e.g. "youtube dQw4w9WgXcQ".
+func archiveID(extractorKey, id string) string {
+	if extractorKey == "" || id == "" {
+		return ""
+	}
+	return strings.ToLower(extractorKey) + " " + id
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// Backend is the download queue the TUI works on: the in-process Engine,
+// or a `mldy serve` daemon reached over HTTP. The screens read the Queue,
+// History and Config a backend keeps current; actions return commands like
+// the Engine's, so the model handles both alike.
+type Backend interface {
+	Config() Config
+	Queue() *Queue
+	History() *History
+	HistoryErr() error
+
+	// Running reports whether downloads are being worked through,
+	// Resolving how many URLs are still being expanded into entries.
+	Running() bool
+	Resolving() int
+
+	// Location names the daemon for a remote backend, "" for a local one;
+	// Err is the last error talking to it.
+	Location() string
+	Err() error
+
+	// Init starts listening for the backend's updates; Update applies the
+	// messages its commands produce and reports whether msg was one.
+	Init() tea.Cmd
+	Update(msg tea.Msg) (tea.Cmd, bool)
+
+	Add(urls []string, config EntryConfig) tea.Cmd
+	Start() tea.Cmd
+	Pause(id int) tea.Cmd
+	Resume(id int) tea.Cmd
+	Cancel(id int) tea.Cmd
+	Redownload(id int) tea.Cmd
+
+	// Remove drops a queued entry that hasn't started.
+	Remove(id int) tea.Cmd
+	// SetEntryConfig replaces the override of the given entries that are
+	// still queued.
+	SetEntryConfig(ids []int, config EntryConfig) tea.Cmd
+	// SetConfig saves a new global config for downloads started from now on.
+	SetConfig(config Config) (tea.Cmd, error)
+
+	// Close is called when the TUI quits. A local backend stops its
+	// downloads; a daemon keeps going.
+	Close()
+}
+
+// localBackend runs the queue in this process.
+type localBackend struct {
+	engine *Engine
+}
+
+// openLocalBackend sets up an engine on the saved config, queue, history
+// and archive, falling back to empty ones that can't be read. A queue that
+// can't be restored shows its error on the Input screen.
+func openLocalBackend(runtime string) *localBackend {
+	config, _ := loadConfig()
+
+	queue := NewQueue()
+	if path, err := queuePath(); err == nil {
+		queue, _ = OpenQueue(path)
+	}
+
+	history := &History{}
+	if path, err := historyPath(); err == nil {
+		history, _ = OpenHistory(path)
+	}
+
+	var archive *Archive
+	if path, err := archivePath(); err == nil {
+		archive = OpenArchive(path)
+	}
+
+	return &localBackend{
+		engine: NewEngine(config, queue, NewDownloader(config, runtime, archive.Path()), history, archive),
+	}
+}
+
+func (b *localBackend) Config() Config        { return b.engine.config }
+func (b *localBackend) Queue() *Queue         { return b.engine.queue }
+func (b *localBackend) History() *History     { return b.engine.history }
+func (b *localBackend) HistoryErr() error     { return b.engine.historyErr }
+func (b *localBackend) Running() bool         { return b.engine.isRunning }
+func (b *localBackend) Resolving() int        { return b.engine.resolvingCount }
+func (b *localBackend) Location() string      { return "" }
+func (b *localBackend) Err() error            { return nil }
+func (b *localBackend) Start() tea.Cmd        { return b.engine.Start() }
+func (b *localBackend) Pause(id int) tea.Cmd  { return b.engine.Pause(id) }
+func (b *localBackend) Resume(id int) tea.Cmd { return b.engine.Resume(id) }
+func (b *localBackend) Cancel(id int) tea.Cmd { return b.engine.Cancel(id) }
+func (b *localBackend) Close()                { b.engine.StopAll() }
+
+func (b *localBackend) Redownload(id int) tea.Cmd { return b.engine.Redownload(id) }
+
+// Init starts the single listener that drains the engine's progress
+// channel for the whole session; every message read from it re-arms it.
+func (b *localBackend) Init() tea.Cmd {
+	return listenProgress(b.engine.Progress())
+}
+
+func (b *localBackend) Update(msg tea.Msg) (tea.Cmd, bool) {
+	switch msg.(type) {
+	case PlaylistResolvedMsg, DownloadCompleteMsg, RetryDueMsg:
+		return b.engine.Update(msg), true
+	case ProgressMsg:
+		return tea.Batch(b.engine.Update(msg), listenProgress(b.engine.Progress())), true
+	}
+	return nil, false
+}
+
+func (b *localBackend) Add(urls []string, config EntryConfig) tea.Cmd {
+	return b.engine.ResolveAll(urls, config)
+}
+
+func (b *localBackend) Remove(id int) tea.Cmd {
+	if e := b.engine.queue.GetByID(id); e != nil && e.Status == StatusQueued {
+		b.engine.queue.Remove(id)
+	}
+	return nil
+}
+
+func (b *localBackend) SetEntryConfig(ids []int, config EntryConfig) tea.Cmd {
+	for _, id := range ids {
+		b.engine.queue.Update(id, func(e *DownloadEntry) {
+			// Only entries still waiting can change; one may have started
+			// while the editor was open.
+			if e.Status == StatusQueued {
+				e.Config = config
+			}
+		})
+	}
+	return nil
+}
+
+// SetConfig writes config.yaml and hands the config to the engine. A
+// higher parallel limit can start more downloads right away.
+func (b *localBackend) SetConfig(config Config) (tea.Cmd, error) {
+	if err := saveConfig(config); err != nil {
+		return nil, fmt.Errorf("could not save config: %w", err)
+	}
+	b.engine.SetConfig(config)
+	if b.engine.isRunning {
+		return b.engine.fillDownloadSlots(), nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// chapterFileTemplate names the files --split-chapters produces, in a
+// folder named after the video next to where the whole video goes.
+const chapterFileTemplate = "%(section_number)02d - %(section_title)s.%(ext)s"
+
+// chapterArgs returns the yt-dlp flags for cfg's chapter settings.
+func chapterArgs(cfg Config, entry DownloadEntry) []string {
+	var args []string
+	if cfg.EmbedChapters {
+		args = append(args, "--embed-chapters")
+	}
+	if cfg.SplitChapters {
+		dir := filepath.Dir(outputTemplate(cfg, entry))
+		args = append(args,
+			"--split-chapters",
+			"-o", "chapter:"+filepath.Join(dir, "%(title)s", chapterFileTemplate),
+		)
+	}
+	return args
+}
+
+// chapterDestination extracts the file from a line like
+// "[SplitChapters] Chapter 003; Destination: /path/03 - Intro.mp3".
+func chapterDestination(line string) (string, bool) {
+	if !strings.HasPrefix(line, "[SplitChapters]") {
+		return "", false
+	}
+	_, path, ok := strings.Cut(line, "Destination: ")
+	return strings.TrimSpace(path), ok
+}
+
+// tagChapterFiles tags each chapter file as a track of an album named
+// after the video: the title comes from the file name ("03 - Intro.mp3"),
+// the track number from its position. ffmpeg copies the streams, so this
+// is quick. Tagging is best effort; a file that fails keeps its name as
+// the only record of its title.
+func tagChapterFiles(files []string, album string) {
+	for i, path := range files {
+		ext := filepath.Ext(path)
+		title := strings.TrimSuffix(filepath.Base(path), ext)
+		if _, rest, ok := strings.Cut(title, " - "); ok {
+			title = rest
+		}
+
+		tmp := strings.TrimSuffix(path, ext) + ".tagging" + ext
+		cmd := exec.Command("ffmpeg", "-v", "error", "-y", "-i", path,
+			"-map", "0", "-c", "copy",
+			"-metadata", "title="+title,
+			"-metadata", "album="+album,
+			"-metadata", fmt.Sprintf("track=%s/%d", strconv.Itoa(i+1), len(files)),
+			tmp,
+		)
+		if err := cmd.Run(); err != nil {
+			os.Remove(tmp)
+			continue
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			os.Remove(tmp)
+		}
+	}
+}
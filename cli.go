@@ -0,0 +1,364 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// Exit codes of `mldy get`.
+const (
+	exitOK         = 0
+	exitSomeFailed = 1
+	exitUsage      = 2
+	exitAllFailed  = 3 // also used when nothing could be started at all
+)
+
+// getOptions are the `mldy get` flags. Empty strings mean "not given" so
+// only explicit flags override config.yaml.
+type getOptions struct {
+	kind         string
+	format       string
+	audioQuality string
+	videoQuality string
+	outputFolder string
+	profile      string
+	concurrent   int
+	rateLimit    string
+	force        bool
+	subs         string
+	attempts     int
+	files        fileList
+}
+
+func parseGetArgs(args []string, stderr io.Writer) (getOptions, []string, error) {
+	var opts getOptions
+
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: mldy get [flags] [URL...]")
+		fmt.Fprintln(stderr, "\nDownloads the given URLs without the TUI. Flags override config.yaml.")
+		fmt.Fprintln(stderr, "Without URLs or -a, URLs are read from stdin, one per line.")
+		fmt.Fprintln(stderr, "\nFlags:")
+		fs.PrintDefaults()
+		fmt.Fprintln(stderr, "\nExit status: 0 all downloaded, 1 some failed, 2 usage error, 3 all failed.")
+	}
+	fs.StringVar(&opts.kind, "kind", "", "output kind: audio, video or auto")
+	fs.StringVar(&opts.format, "format", "", "output format, e.g. mp3, m4a, mp4, mkv")
+	fs.StringVar(&opts.audioQuality, "audio-quality", "", "VBR 0-10 or a bitrate like 192K")
+	fs.StringVar(&opts.videoQuality, "video-quality", "", "best or a height like 720p")
+	fs.StringVar(&opts.outputFolder, "o", "", "output folder")
+	fs.StringVar(&opts.profile, "profile", "", "use a profile from config.yaml; other flags still override it")
+	fs.IntVar(&opts.concurrent, "j", 0, "number of parallel downloads")
+	fs.StringVar(&opts.rateLimit, "r", "", "total download rate limit in bytes/s, e.g. 500K or 2M")
+	fs.StringVar(&opts.subs, "subs", "", "download subtitles in these languages, e.g. en,de or all")
+	fs.BoolVar(&opts.force, "force", false, "download videos again even if they are in the download archive")
+	fs.IntVar(&opts.attempts, "attempts", 0, "maximum attempts per entry, including the first")
+	fs.Var(&opts.files, "a", "read URLs from a file (\"-\" for stdin); may be repeated")
+
+	if err := fs.Parse(args); err != nil {
+		return opts, nil, err
+	}
+
+	if opts.kind != "" && !OutputKind(opts.kind).IsValid() {
+		return opts, nil, fmt.Errorf("invalid -kind %q", opts.kind)
+	}
+	if opts.audioQuality != "" && !AudioQuality(opts.audioQuality).IsValid() {
+		return opts, nil, fmt.Errorf("invalid -audio-quality %q", opts.audioQuality)
+	}
+	if !RateLimit(opts.rateLimit).IsValid() {
+		return opts, nil, fmt.Errorf("invalid -r %q", opts.rateLimit)
+	}
+	if fs.NArg() == 0 && len(opts.files) == 0 && !stdinIsPiped() {
+		fs.Usage()
+		return opts, nil, errors.New("no URLs given")
+	}
+	return opts, fs.Args(), nil
+}
+
+// apply layers the flags onto cfg: pool and retry settings go into the
+// global config, output settings become the per-entry override on top of
+// the chosen profile.
+func (o getOptions) apply(cfg Config) (Config, EntryConfig, error) {
+	entry, ok := cfg.Profile(o.profile)
+	if !ok {
+		names := cfg.ProfileNames()
+		if len(names) == 0 {
+			return cfg, entry, fmt.Errorf("unknown profile %q: config.yaml defines no profiles", o.profile)
+		}
+		return cfg, entry, fmt.Errorf("unknown profile %q (have: %s)", o.profile, strings.Join(names, ", "))
+	}
+	if o.kind != "" {
+		kind := OutputKind(o.kind)
+		entry.Kind = &kind
+	}
+	if o.format != "" {
+		entry.Format = &o.format
+	}
+	if o.audioQuality != "" {
+		q := AudioQuality(o.audioQuality)
+		entry.AudioQuality = &q
+	}
+	if o.videoQuality != "" {
+		entry.VideoQuality = &o.videoQuality
+	}
+	if o.outputFolder != "" {
+		entry.OutputFolder = &o.outputFolder
+	}
+	if o.subs != "" {
+		entry.SubtitleLangs = &o.subs
+	}
+	if o.force {
+		skip := false
+		entry.SkipDownloaded = &skip
+	}
+	if o.concurrent > 0 {
+		cfg.MaxConcurrent = o.concurrent
+	}
+	if o.rateLimit != "" {
+		cfg.RateLimit = RateLimit(o.rateLimit)
+	}
+	if o.attempts > 0 {
+		cfg.Retry.MaxAttempts = o.attempts
+	}
+	return cfg, entry, nil
+}
+
+// runGet implements `mldy get`: it downloads without the TUI, printing one
+// line per event, and returns the process exit code.
+func runGet(args []string) int {
+	opts, rest, err := parseGetArgs(args, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "mldy get:", err)
+		return exitUsage
+	}
+	urls, err := collectURLs(rest, opts.files)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "mldy get:", err)
+		return exitUsage
+	}
+	if len(urls) == 0 {
+		fmt.Fprintln(os.Stderr, "mldy get: no URLs given")
+		return exitUsage
+	}
+
+	config, _ := loadConfig()
+	config, entryConfig, err := opts.apply(config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "mldy get:", err)
+		return exitUsage
+	}
+
+	if _, err := exec.LookPath("yt-dlp"); err != nil {
+		fmt.Fprintln(os.Stderr, "mldy get: yt-dlp not found; run mldy once interactively to install it")
+		return exitAllFailed
+	}
+	runtime, _, _ := detectRuntime()
+
+	history := &History{}
+	if path, err := historyPath(); err == nil {
+		history, _ = OpenHistory(path)
+	}
+	var archive *Archive
+	if path, err := archivePath(); err == nil {
+		archive = OpenArchive(path)
+	}
+
+	// The headless queue stays in memory so it can't clash with a TUI
+	// running at the same time.
+	engine := NewEngine(config, NewQueue(), NewDownloader(config, runtime, archive.Path()), history, archive)
+	loop := newHeadlessLoop(engine, os.Stdout)
+	loop.run(engine.ResolveAll(urls, entryConfig))
+	loop.wait()
+
+	var completed, skipped, failed int
+	for _, e := range engine.queue.Entries {
+		switch e.Status {
+		case StatusCompleted:
+			completed++
+		case StatusSkipped:
+			skipped++
+		case StatusFailed, StatusCanceled:
+			failed++
+		}
+	}
+	fmt.Printf("%d downloaded, %d skipped, %d failed\n", completed, skipped, failed)
+
+	// Skipped entries were downloaded before, which is what was asked for.
+	completed += skipped
+	switch {
+	case failed == 0 && completed > 0:
+		return exitOK
+	case completed > 0:
+		return exitSomeFailed
+	default:
+		return exitAllFailed
+	}
+}
+
+// headlessLoop drives an Engine without Bubble Tea: commands run on their
+// own goroutines and the messages they return are fed back into
+// Engine.Update one at a time, which is all the Bubble Tea runtime does for
+// the TUI.
+type headlessLoop struct {
+	engine  *Engine
+	out     io.Writer
+	msgs    chan tea.Msg
+	pending int
+
+	// lastStep remembers the last 10% progress step printed per entry,
+	// lastStage the last post-processing stage.
+	lastStep  map[int]int
+	lastStage map[int]DownloadStage
+
+	// skipped holds the skipped entries already reported.
+	skipped map[int]bool
+}
+
+func newHeadlessLoop(engine *Engine, out io.Writer) *headlessLoop {
+	return &headlessLoop{
+		engine:    engine,
+		out:       out,
+		msgs:      make(chan tea.Msg),
+		lastStep:  make(map[int]int),
+		lastStage: make(map[int]DownloadStage),
+		skipped:   make(map[int]bool),
+	}
+}
+
+func (l *headlessLoop) run(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	l.pending++
+	go func() { l.msgs <- cmd() }()
+}
+
+// wait processes messages until no command is left running.
+func (l *headlessLoop) wait() {
+	for l.pending > 0 {
+		select {
+		case msg := <-l.msgs:
+			// A download sends all of its progress before it returns, so
+			// draining first keeps "done" after the last progress line.
+			l.drainProgress()
+			l.pending--
+			l.dispatch(msg)
+		case msg := <-l.engine.Progress():
+			l.dispatch(msg)
+		}
+	}
+}
+
+func (l *headlessLoop) drainProgress() {
+	for {
+		select {
+		case msg := <-l.engine.Progress():
+			l.dispatch(msg)
+		default:
+			return
+		}
+	}
+}
+
+func (l *headlessLoop) dispatch(msg tea.Msg) {
+	switch msg := msg.(type) {
+	case nil:
+	case tea.BatchMsg:
+		for _, cmd := range msg {
+			l.run(cmd)
+		}
+	case PlaylistResolvedMsg:
+		cmd := l.engine.Update(msg)
+		l.report(msg)
+		// Start as soon as the first URL is resolved; later ones join the
+		// running pool.
+		l.run(tea.Batch(cmd, l.engine.Start()))
+	default:
+		cmd := l.engine.Update(msg)
+		l.report(msg)
+		l.run(cmd)
+	}
+}
+
+// report prints one line for the messages worth logging.
+func (l *headlessLoop) report(msg tea.Msg) {
+	switch msg := msg.(type) {
+	case PlaylistResolvedMsg:
+		switch {
+		case msg.Error != nil:
+			fmt.Fprintf(l.out, "resolve failed: %s: %v\n", msg.OriginalURL, msg.Error)
+		case msg.PlaylistTitle != "":
+			fmt.Fprintf(l.out, "resolved playlist %q: %d item(s)\n", msg.PlaylistTitle, len(msg.Items))
+		}
+		for _, e := range l.engine.queue.GetSkipped() {
+			if !l.skipped[e.ID] {
+				l.skipped[e.ID] = true
+				fmt.Fprintf(l.out, "[#%d] skipped, downloaded before: %s%s\n", e.ID, e.PlaylistLabel(), e.DisplayTitle())
+			}
+		}
+
+	case ProgressMsg:
+		if msg.Stage.PostProcessing() {
+			if l.lastStage[msg.ID] != msg.Stage {
+				l.lastStage[msg.ID] = msg.Stage
+				fmt.Fprintf(l.out, "[#%d] %s...\n", msg.ID, strings.ToLower(msg.Stage.String()))
+			}
+			return
+		}
+		step := int(msg.Progress) / 10
+		if last, ok := l.lastStep[msg.ID]; ok && step <= last {
+			return
+		}
+		l.lastStep[msg.ID] = step
+		if e := l.engine.queue.GetByID(msg.ID); e != nil {
+			line := fmt.Sprintf("[#%d] %5.1f%%  %s%s", msg.ID, msg.Progress, e.PlaylistLabel(), e.DisplayTitle())
+			if details := transferSummary(*e); details != "" {
+				line += "  (" + details + ")"
+			}
+			fmt.Fprintln(l.out, line)
+		}
+
+	case DownloadCompleteMsg:
+		e := l.engine.queue.GetByID(msg.ID)
+		if e == nil {
+			return
+		}
+		switch e.Status {
+		case StatusCompleted:
+			if n := len(e.OutputFiles); n > 0 {
+				fmt.Fprintf(l.out, "[#%d] done: %s (+%d chapter files)\n", e.ID, e.OutputPath, n)
+			} else {
+				fmt.Fprintf(l.out, "[#%d] done: %s\n", e.ID, e.OutputPath)
+			}
+		case StatusQueued:
+			delete(l.lastStep, e.ID)
+			delete(l.lastStage, e.ID)
+			fmt.Fprintf(l.out, "[#%d] attempt %d/%d failed, retrying in %s: %s\n",
+				e.ID, len(e.Attempts), l.engine.config.Retry.MaxAttempts,
+				time.Until(e.RetryAt).Round(time.Second), lastLine(e.Error))
+		case StatusSkipped:
+			fmt.Fprintf(l.out, "[#%d] skipped, downloaded before\n", e.ID)
+		case StatusFailed:
+			fmt.Fprintf(l.out, "[#%d] failed: %s\n", e.ID, lastLine(e.Error))
+		}
+	}
+}
+
+// lastLine returns the last non-empty line of s, which for yt-dlp errors is
+// usually the "ERROR: ..." line that explains what went wrong.
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
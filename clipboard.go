@@ -0,0 +1,215 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/atotto/clipboard"
+)
+
+const (
+	// clipboardPollInterval is how often the watcher reads the clipboard.
+	clipboardPollInterval = time.Second
+	// toastDuration is how long a toast stays on the Input screen.
+	toastDuration = 4 * time.Second
+	// maxClipboardURLs caps what one clipboard change queues, so copying a
+	// whole chat log doesn't flood the queue.
+	maxClipboardURLs = 10
+)
+
+// siteAliases map short and alternative domains to the yt-dlp extractor
+// that handles them, for sites whose domain doesn't carry that name.
+var siteAliases = map[string]string{
+	"youtu": "youtube",
+	"x":     "twitter",
+	"fb":    "facebook",
+	"redd":  "reddit",
+}
+
+// ytDlpSitesCache holds the extractor list once it was read, guarded by
+// ytDlpSitesMu.
+var (
+	ytDlpSitesMu    sync.Mutex
+	ytDlpSitesCache map[string]bool
+)
+
+// ytDlpSites returns the site names of yt-dlp's extractors, like "youtube"
+// or "vimeo", without the generic one that accepts any page. The list is
+// kept once it was read; until then every call tries again, so a yt-dlp
+// installed in the meantime is picked up.
+func ytDlpSites() (map[string]bool, error) {
+	ytDlpSitesMu.Lock()
+	defer ytDlpSitesMu.Unlock()
+	if ytDlpSitesCache != nil {
+		return ytDlpSitesCache, nil
+	}
+
+	out, err := exec.Command("yt-dlp", "--list-extractors").Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not list yt-dlp's extractors: %w", err)
+	}
+	sites := make(map[string]bool)
+	for line := range strings.Lines(string(out)) {
+		// Lines look like "youtube:tab" or "Foo (CURRENTLY BROKEN)".
+		fields := strings.Fields(strings.ToLower(line))
+		if len(fields) == 0 {
+			continue
+		}
+		if name, _, _ := strings.Cut(fields[0], ":"); name != "generic" {
+			sites[name] = true
+		}
+	}
+	ytDlpSitesCache = sites
+	return sites, nil
+}
+
+// supportedSite reports whether a dedicated extractor covers host: one of
+// its labels, e.g. "vimeo" in player.vimeo.com, names an extractor, or the
+// whole domain does, as for "archive.org".
+func supportedSite(host string, sites map[string]bool) bool {
+	labels := strings.Split(host, ".")
+	for _, label := range labels[:len(labels)-1] { // not the TLD
+		if alias, ok := siteAliases[label]; ok {
+			label = alias
+		}
+		if sites[label] || sites[label+"."+labels[len(labels)-1]] {
+			return true
+		}
+	}
+	return false
+}
+
+// ClipboardMsg carries the clipboard's content and yt-dlp's sites, nil
+// without a local yt-dlp; Skipped is set when watching was off and the
+// clipboard wasn't read.
+type ClipboardMsg struct {
+	Text    string
+	Sites   map[string]bool
+	Err     error
+	Skipped bool
+}
+
+// ToastExpiredMsg hides toast ID unless a newer one replaced it.
+type ToastExpiredMsg struct {
+	ID int
+}
+
+// pollClipboard reads the clipboard after clipboardPollInterval, or only
+// reports back when watching is off, so turning it on in Settings takes
+// effect on the next tick.
+func pollClipboard(watch bool) tea.Cmd {
+	return tea.Tick(clipboardPollInterval, func(time.Time) tea.Msg {
+		if !watch {
+			return ClipboardMsg{Skipped: true}
+		}
+		// Without a local yt-dlp, e.g. attached to a daemon on another
+		// machine, there's no list to check links against.
+		sites, err := ytDlpSites()
+		if err != nil && !errors.Is(err, exec.ErrNotFound) {
+			return ClipboardMsg{Err: err}
+		}
+		text, err := clipboard.ReadAll()
+		if err != nil {
+			err = fmt.Errorf("can't read the clipboard: %w", err)
+		}
+		return ClipboardMsg{Text: text, Sites: sites, Err: err}
+	})
+}
+
+// clipboardURLs picks the links from text that one of yt-dlp's sites
+// handles, or any web link when sites is nil, outside the comma-separated
+// deny sites.
+func clipboardURLs(text, deny string, sites map[string]bool) []string {
+	var denied []string
+	for site := range strings.SplitSeq(normalizeList(deny), ",") {
+		if site != "" {
+			denied = append(denied, strings.TrimPrefix(site, "www."))
+		}
+	}
+
+	var urls []string
+	for _, field := range strings.Fields(text) {
+		u, err := url.Parse(field)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || !strings.Contains(u.Host, ".") {
+			continue
+		}
+		host := strings.ToLower(u.Hostname())
+		if sites != nil && !supportedSite(strings.TrimPrefix(host, "www."), sites) {
+			continue
+		}
+		if slices.ContainsFunc(denied, func(site string) bool {
+			return host == site || strings.HasSuffix(host, "."+site)
+		}) {
+			continue
+		}
+		if !slices.Contains(urls, field) {
+			urls = append(urls, field)
+		}
+	}
+	return urls
+}
+
+// updateClipboard queues new links found on the clipboard and re-arms the
+// watcher. Content already on the clipboard when watching starts is left
+// alone, and no URL is queued twice.
+func (m *Model) updateClipboard(msg ClipboardMsg) tea.Cmd {
+	next := pollClipboard(m.backend.Config().ClipboardWatch)
+	switch {
+	case msg.Skipped:
+		m.clipboardPrimed = false
+		m.clipboardErr = nil
+		return next
+	case msg.Err != nil:
+		m.clipboardErr = msg.Err
+		return next
+	}
+	m.clipboardErr = nil
+	if !m.clipboardPrimed || msg.Text == m.clipboardText {
+		m.clipboardPrimed = true
+		m.clipboardText = msg.Text
+		return next
+	}
+	m.clipboardText = msg.Text
+
+	var urls []string
+	found := 0
+	for _, u := range clipboardURLs(msg.Text, m.backend.Config().ClipboardDeny, msg.Sites) {
+		if m.clipboardSeen[u] || slices.ContainsFunc(m.backend.Queue().Entries, func(e DownloadEntry) bool { return e.URL == u }) {
+			continue
+		}
+		found++
+		if len(urls) < maxClipboardURLs {
+			m.clipboardSeen[u] = true
+			urls = append(urls, u)
+		}
+	}
+	if len(urls) == 0 {
+		return next
+	}
+
+	toast := fmt.Sprintf("📋 Queued %d links from the clipboard", len(urls))
+	if found > len(urls) {
+		toast = fmt.Sprintf("📋 Queued the first %d of %d links from the clipboard", len(urls), found)
+	} else if len(urls) == 1 {
+		toast = "📋 Queued from the clipboard: " + urls[0]
+	}
+	if m.profile != "" {
+		toast += " (" + m.profile + ")"
+	}
+	return tea.Batch(next, m.backend.Add(urls, m.newEntryConfig()), m.showToast(toast))
+}
+
+// showToast puts text on the Input screen for toastDuration.
+func (m *Model) showToast(text string) tea.Cmd {
+	m.toastID++
+	m.toast = text
+	id := m.toastID
+	return tea.Tick(toastDuration, func(time.Time) tea.Msg { return ToastExpiredMsg{ID: id} })
+}
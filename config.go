@@ -1,10 +1,14 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/goccy/go-yaml"
 )
@@ -48,6 +52,73 @@ func (q AudioQuality) IsValid() bool {
 	return n >= 0 && n <= 10
 }
 
+// RateLimit is a download speed in bytes per second, either a plain number
+// or one with a K, M or G suffix like "500K" or "2M". Empty or "0" means
+// unlimited.
+type RateLimit string
+
+// Bytes returns the limit in bytes per second, 0 for unlimited.
+func (r RateLimit) Bytes() (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(string(r)))
+	if s == "" {
+		return 0, nil
+	}
+	mult := 1.0
+	switch s[len(s)-1] {
+	case 'K':
+		mult = 1 << 10
+	case 'M':
+		mult = 1 << 20
+	case 'G':
+		mult = 1 << 30
+	}
+	if mult > 1 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid rate limit %q", string(r))
+	}
+	return int64(n * mult), nil
+}
+
+func (r RateLimit) IsValid() bool {
+	_, err := r.Bytes()
+	return err == nil
+}
+
+// SponsorBlockMode is what happens to SponsorBlock segments: "mark" adds
+// them as chapters, "remove" cuts them out of the file.
+type SponsorBlockMode string
+
+const (
+	SponsorBlockOff    SponsorBlockMode = "off"
+	SponsorBlockMark   SponsorBlockMode = "mark"
+	SponsorBlockRemove SponsorBlockMode = "remove"
+)
+
+func (m SponsorBlockMode) IsValid() bool {
+	switch m {
+	case SponsorBlockOff, SponsorBlockMark, SponsorBlockRemove:
+		return true
+	}
+	return false
+}
+
+// sponsorBlockCategories are the SponsorBlock categories mldy offers.
+var sponsorBlockCategories = []string{"sponsor", "intro", "outro", "selfpromo", "music_offtopic"}
+
+// isSponsorBlockCategories reports whether s is a comma-separated list of
+// sponsorBlockCategories.
+func isSponsorBlockCategories(s string) bool {
+	for _, c := range strings.Split(s, ",") {
+		if !slices.Contains(sponsorBlockCategories, strings.TrimSpace(c)) {
+			return false
+		}
+	}
+	return true
+}
+
 // Config is the global configuration, mirroring the TypeScript ConfigSchema.
 type Config struct {
 	Kind         OutputKind   `yaml:"kind"`
@@ -55,6 +126,59 @@ type Config struct {
 	AudioQuality AudioQuality `yaml:"audio_quality"`
 	VideoQuality string       `yaml:"video_quality"`
 	OutputFolder string       `yaml:"output_folder"`
+
+	// OutputTemplate and PlaylistOutputTemplate name the downloaded files,
+	// relative to OutputFolder. They take yt-dlp fields like %(title)s plus
+	// {playlist_title}, {playlist_index} and {playlist_count}.
+	OutputTemplate         string `yaml:"output_template"`
+	PlaylistOutputTemplate string `yaml:"playlist_output_template"`
+
+	// MaxConcurrent is how many yt-dlp processes may run at the same time.
+	MaxConcurrent int `yaml:"max_concurrent"`
+
+	// RateLimit is the bandwidth budget shared by all running downloads.
+	RateLimit RateLimit `yaml:"rate_limit"`
+
+	// SkipDownloaded skips videos already in the download archive.
+	SkipDownloaded bool `yaml:"skip_downloaded"`
+
+	// SubtitleLangs is a yt-dlp --sub-langs list like "en,de" or "all";
+	// empty downloads no subtitles. AutoSubtitles adds auto-generated ones.
+	// Subtitles are converted to SubtitleFormat (srt, vtt or ass) and, for
+	// video, embedded unless EmbedSubtitles is off.
+	SubtitleLangs  string `yaml:"subtitle_langs"`
+	AutoSubtitles  bool   `yaml:"auto_subtitles"`
+	SubtitleFormat string `yaml:"subtitle_format"`
+	EmbedSubtitles bool   `yaml:"embed_subtitles"`
+
+	// EmbedChapters adds chapter markers; SplitChapters also saves one
+	// file per chapter, tagged as tracks of an album.
+	EmbedChapters bool `yaml:"embed_chapters"`
+	SplitChapters bool `yaml:"split_chapters"`
+
+	// SponsorBlock marks or removes the segments in SponsorBlockCategories,
+	// a comma-separated list like "sponsor,selfpromo".
+	SponsorBlock           SponsorBlockMode `yaml:"sponsorblock"`
+	SponsorBlockCategories string           `yaml:"sponsorblock_categories"`
+
+	Retry RetryConfig `yaml:"retry"`
+
+	// ClipboardWatch has the TUI queue media links copied to the clipboard,
+	// except those on ClipboardDeny, a comma-separated list of sites like
+	// "github.com,google.com" (subdomains included).
+	ClipboardWatch bool   `yaml:"clipboard_watch"`
+	ClipboardDeny  string `yaml:"clipboard_deny"`
+
+	// Network applies to every yt-dlp run and to downloads during setup.
+	Network NetworkConfig `yaml:"network,omitempty"`
+
+	// Cookies maps sites like "youtube.com" (subdomains included) to the
+	// cookies yt-dlp logs in with there; "*" applies to all other sites.
+	Cookies map[string]CookieSource `yaml:"cookies,omitempty"`
+
+	// Profiles are named presets, e.g. "podcast" or "phone", picked on the
+	// Input screen or with `mldy get -profile`.
+	Profiles map[string]EntryConfig `yaml:"profiles,omitempty"`
 }
 
 // EntryConfig is a per-entry override of Config (all fields optional).
@@ -64,26 +188,70 @@ type EntryConfig struct {
 	AudioQuality *AudioQuality `yaml:"audio_quality,omitempty"`
 	VideoQuality *string       `yaml:"video_quality,omitempty"`
 	OutputFolder *string       `yaml:"output_folder,omitempty"`
+
+	OutputTemplate         *string `yaml:"output_template,omitempty"`
+	PlaylistOutputTemplate *string `yaml:"playlist_output_template,omitempty"`
+
+	// RateLimit caps this entry further; it never raises it above its share
+	// of the global budget.
+	RateLimit *RateLimit `yaml:"rate_limit,omitempty"`
+
+	// SkipDownloaded set to false forces a re-download.
+	SkipDownloaded *bool `yaml:"skip_downloaded,omitempty"`
+
+	SubtitleLangs  *string `yaml:"subtitle_langs,omitempty"`
+	AutoSubtitles  *bool   `yaml:"auto_subtitles,omitempty"`
+	SubtitleFormat *string `yaml:"subtitle_format,omitempty"`
+	EmbedSubtitles *bool   `yaml:"embed_subtitles,omitempty"`
+
+	EmbedChapters *bool `yaml:"embed_chapters,omitempty"`
+	SplitChapters *bool `yaml:"split_chapters,omitempty"`
+
+	SponsorBlock           *SponsorBlockMode `yaml:"sponsorblock,omitempty"`
+	SponsorBlockCategories *string           `yaml:"sponsorblock_categories,omitempty"`
 }
 
 func defaultConfig() Config {
 	homeDir, _ := os.UserHomeDir()
 	return Config{
-		Kind:         KindAuto,
-		Format:       "mp3",
-		AudioQuality: "5",
-		VideoQuality: "best",
-		OutputFolder: filepath.Join(homeDir, "Downloads", "mldy"),
+		Kind:                   KindAuto,
+		Format:                 "mp3",
+		AudioQuality:           "5",
+		VideoQuality:           "best",
+		OutputFolder:           filepath.Join(homeDir, "Downloads", "mldy"),
+		OutputTemplate:         defaultOutputTemplate,
+		PlaylistOutputTemplate: defaultPlaylistOutputTemplate,
+		MaxConcurrent:          3,
+		SkipDownloaded:         true,
+		SubtitleFormat:         "srt",
+		EmbedSubtitles:         true,
+		EmbedChapters:          true,
+		SponsorBlock:           SponsorBlockOff,
+		SponsorBlockCategories: "sponsor,selfpromo",
+		Retry: RetryConfig{
+			MaxAttempts:       3,
+			BackoffSeconds:    10,
+			MaxBackoffSeconds: 300,
+		},
 	}
 }
 
-func loadConfig() (Config, error) {
+// configDir returns ~/.config/mldy, where mldy keeps all of its files.
+func configDir() (string, error) {
 	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".config", "mldy"), nil
+}
+
+func loadConfig() (Config, error) {
+	dir, err := configDir()
 	if err != nil {
 		return defaultConfig(), nil
 	}
 
-	configPath := filepath.Join(homeDir, ".config", "mldy", "config.yaml")
+	configPath := filepath.Join(dir, "config.yaml")
 
 	data, err := os.ReadFile(configPath)
 	if err != nil {
@@ -106,28 +274,88 @@ func loadConfig() (Config, error) {
 	if !cfg.AudioQuality.IsValid() {
 		cfg.AudioQuality = "5"
 	}
+	if cfg.OutputTemplate == "" {
+		cfg.OutputTemplate = defaultOutputTemplate
+	}
+	if cfg.PlaylistOutputTemplate == "" {
+		cfg.PlaylistOutputTemplate = defaultPlaylistOutputTemplate
+	}
+	if cfg.MaxConcurrent < 1 {
+		cfg.MaxConcurrent = 1
+	}
+	if !cfg.RateLimit.IsValid() {
+		cfg.RateLimit = ""
+	}
+	if !isSubtitleFormat(cfg.SubtitleFormat) {
+		cfg.SubtitleFormat = "srt"
+	}
+	if !cfg.SponsorBlock.IsValid() {
+		cfg.SponsorBlock = SponsorBlockOff
+	}
+	if !isSponsorBlockCategories(cfg.SponsorBlockCategories) {
+		cfg.SponsorBlockCategories = "sponsor,selfpromo"
+	}
+	if cfg.Retry.MaxAttempts < 1 {
+		cfg.Retry.MaxAttempts = 1
+	}
+	if cfg.Retry.BackoffSeconds < 0 {
+		cfg.Retry.BackoffSeconds = 0
+	}
+	if cfg.Network.validate() != nil {
+		cfg.Network = NetworkConfig{Sites: cfg.Network.Sites}
+	}
+	for site, network := range cfg.Network.Sites {
+		if network.validate() != nil {
+			delete(cfg.Network.Sites, site)
+		}
+	}
+	for name, profile := range cfg.Profiles {
+		if profile.Kind != nil && !profile.Kind.IsValid() {
+			profile.Kind = nil
+		}
+		if profile.AudioQuality != nil && !profile.AudioQuality.IsValid() {
+			profile.AudioQuality = nil
+		}
+		if profile.RateLimit != nil && !profile.RateLimit.IsValid() {
+			profile.RateLimit = nil
+		}
+		if profile.SubtitleFormat != nil && !isSubtitleFormat(*profile.SubtitleFormat) {
+			profile.SubtitleFormat = nil
+		}
+		if profile.SponsorBlock != nil && !profile.SponsorBlock.IsValid() {
+			profile.SponsorBlock = nil
+		}
+		if profile.SponsorBlockCategories != nil && !isSponsorBlockCategories(*profile.SponsorBlockCategories) {
+			profile.SponsorBlockCategories = nil
+		}
+		cfg.Profiles[name] = profile
+	}
 
 	return cfg, nil
 }
 
 func saveConfig(cfg Config) error {
-	homeDir, err := os.UserHomeDir()
+	dir, err := configDir()
 	if err != nil {
 		return err
 	}
 
-	configDir := filepath.Join(homeDir, ".config", "mldy")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
 	}
 
-	configPath := filepath.Join(configDir, "config.yaml")
+	configPath := filepath.Join(dir, "config.yaml")
 	data, err := yaml.Marshal(cfg)
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(configPath, data, 0644)
+	// The network block can hold proxy credentials, so only this user may
+	// read the file. WriteFile keeps the mode of an existing one.
+	if err := os.WriteFile(configPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Chmod(configPath, 0600)
 }
 
 func (c Config) MergeWith(entry EntryConfig) Config {
@@ -147,5 +375,146 @@ func (c Config) MergeWith(entry EntryConfig) Config {
 	if entry.OutputFolder != nil {
 		merged.OutputFolder = *entry.OutputFolder
 	}
+	if entry.OutputTemplate != nil {
+		merged.OutputTemplate = *entry.OutputTemplate
+	}
+	if entry.PlaylistOutputTemplate != nil {
+		merged.PlaylistOutputTemplate = *entry.PlaylistOutputTemplate
+	}
+	if entry.RateLimit != nil {
+		merged.RateLimit = *entry.RateLimit
+	}
+	if entry.SkipDownloaded != nil {
+		merged.SkipDownloaded = *entry.SkipDownloaded
+	}
+	if entry.SubtitleLangs != nil {
+		merged.SubtitleLangs = *entry.SubtitleLangs
+	}
+	if entry.AutoSubtitles != nil {
+		merged.AutoSubtitles = *entry.AutoSubtitles
+	}
+	if entry.SubtitleFormat != nil {
+		merged.SubtitleFormat = *entry.SubtitleFormat
+	}
+	if entry.EmbedSubtitles != nil {
+		merged.EmbedSubtitles = *entry.EmbedSubtitles
+	}
+	if entry.EmbedChapters != nil {
+		merged.EmbedChapters = *entry.EmbedChapters
+	}
+	if entry.SplitChapters != nil {
+		merged.SplitChapters = *entry.SplitChapters
+	}
+	if entry.SponsorBlock != nil {
+		merged.SponsorBlock = *entry.SponsorBlock
+	}
+	if entry.SponsorBlockCategories != nil {
+		merged.SponsorBlockCategories = *entry.SponsorBlockCategories
+	}
 	return merged
 }
+
+// ProfileNames returns the configured profile names in sorted order.
+func (c Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Profile returns the named profile's override. The empty name is the
+// "no profile" choice and yields a zero override.
+func (c Config) Profile(name string) (EntryConfig, bool) {
+	if name == "" {
+		return EntryConfig{}, true
+	}
+	profile, ok := c.Profiles[name]
+	return profile, ok
+}
+
+// EffectiveKind resolves KindAuto from the output format.
+func (c Config) EffectiveKind() OutputKind {
+	if c.Kind != KindAuto {
+		return c.Kind
+	}
+	switch c.Format {
+	case "mp3", "m4a", "opus", "flac", "wav", "aac":
+		return KindAudio
+	default:
+		return KindVideo
+	}
+}
+
+// Summary is a one-line description of what a download with this config
+// produces, e.g. "audio mp3 q5" or "video mp4 720p".
+func (c Config) Summary() string {
+	if c.EffectiveKind() == KindAudio {
+		return fmt.Sprintf("audio %s q%s", c.Format, c.AudioQuality)
+	}
+	return fmt.Sprintf("video %s %s", c.Format, c.VideoQuality)
+}
+
+// IsZero reports whether the override changes nothing.
+func (e EntryConfig) IsZero() bool {
+	return e.Kind == nil && e.Format == nil && e.AudioQuality == nil &&
+		e.VideoQuality == nil && e.OutputFolder == nil &&
+		e.OutputTemplate == nil && e.PlaylistOutputTemplate == nil &&
+		e.RateLimit == nil && e.SkipDownloaded == nil &&
+		e.SubtitleLangs == nil && e.AutoSubtitles == nil &&
+		e.SubtitleFormat == nil && e.EmbedSubtitles == nil &&
+		e.EmbedChapters == nil && e.SplitChapters == nil &&
+		e.SponsorBlock == nil && e.SponsorBlockCategories == nil
+}
+
+// validate returns an error for the first value the Settings screen would
+// reject.
+func (c Config) validate() error {
+	switch {
+	case c.OutputFolder == "":
+		return errors.New("output folder can't be empty")
+	case c.OutputTemplate == "" || c.PlaylistOutputTemplate == "":
+		return errors.New("output templates can't be empty")
+	case c.MaxConcurrent < 1:
+		return fmt.Errorf("parallel downloads must be at least 1, not %d", c.MaxConcurrent)
+	}
+	return EntryConfig{
+		Kind:                   &c.Kind,
+		AudioQuality:           &c.AudioQuality,
+		RateLimit:              &c.RateLimit,
+		SubtitleFormat:         &c.SubtitleFormat,
+		SponsorBlock:           &c.SponsorBlock,
+		SponsorBlockCategories: &c.SponsorBlockCategories,
+	}.validate()
+}
+
+// validate returns an error for the first value loadConfig would discard
+// from a profile.
+func (e EntryConfig) validate() error {
+	switch {
+	case e.Kind != nil && !e.Kind.IsValid():
+		return fmt.Errorf("kind must be audio, video or auto, not %q", *e.Kind)
+	case e.AudioQuality != nil && !e.AudioQuality.IsValid():
+		return fmt.Errorf("audio quality must be 0–10 or a bitrate like 192K, not %q", *e.AudioQuality)
+	case e.RateLimit != nil && !e.RateLimit.IsValid():
+		return fmt.Errorf("rate limit must be bytes/s like 500K or 2M, not %q", *e.RateLimit)
+	case e.SubtitleFormat != nil && !isSubtitleFormat(*e.SubtitleFormat):
+		return fmt.Errorf("subtitle format must be srt, vtt or ass, not %q", *e.SubtitleFormat)
+	case e.SponsorBlock != nil && !e.SponsorBlock.IsValid():
+		return fmt.Errorf("sponsorblock must be off, mark or remove, not %q", *e.SponsorBlock)
+	case e.SponsorBlockCategories != nil && !isSponsorBlockCategories(*e.SponsorBlockCategories):
+		return fmt.Errorf("sponsorblock categories must be a list of %s, not %q",
+			strings.Join(sponsorBlockCategories, ", "), *e.SponsorBlockCategories)
+	}
+	return nil
+}
+
+// isSubtitleFormat reports whether yt-dlp can convert subtitles to f.
+func isSubtitleFormat(f string) bool {
+	switch f {
+	case "srt", "vtt", "ass":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,573 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	zone "github.com/lrstanley/bubblezone/v2"
+)
+
+// configField identifies one editable setting on a configForm.
+type configField int
+
+const (
+	fieldKind configField = iota
+	fieldFormat
+	fieldAudioQuality
+	fieldVideoQuality
+	fieldOutputFolder
+	fieldOutputTemplate
+	fieldPlaylistOutputTemplate
+	fieldRateLimit
+	fieldSkipDownloaded
+	fieldSubtitleLangs
+	fieldAutoSubtitles
+	fieldSubtitleFormat
+	fieldEmbedSubtitles
+	fieldEmbedChapters
+	fieldSplitChapters
+	fieldSponsorBlock
+	fieldSponsorBlockCategories
+	fieldMaxConcurrent
+	fieldClipboardWatch
+	fieldClipboardDeny
+)
+
+func (f configField) label() string {
+	switch f {
+	case fieldKind:
+		return "Kind"
+	case fieldFormat:
+		return "Format"
+	case fieldAudioQuality:
+		return "Audio Quality"
+	case fieldVideoQuality:
+		return "Video Quality"
+	case fieldOutputFolder:
+		return "Output Folder"
+	case fieldOutputTemplate:
+		return "Name Template"
+	case fieldPlaylistOutputTemplate:
+		return "List Template"
+	case fieldRateLimit:
+		return "Rate Limit"
+	case fieldSkipDownloaded:
+		return "Skip Archived"
+	case fieldSubtitleLangs:
+		return "Subtitles"
+	case fieldAutoSubtitles:
+		return "Auto Subs"
+	case fieldSubtitleFormat:
+		return "Sub Format"
+	case fieldEmbedSubtitles:
+		return "Embed Subs"
+	case fieldEmbedChapters:
+		return "Chapters"
+	case fieldSplitChapters:
+		return "Chapter Files"
+	case fieldSponsorBlock:
+		return "SponsorBlock"
+	case fieldSponsorBlockCategories:
+		return "Segments"
+	case fieldMaxConcurrent:
+		return "Parallel"
+	case fieldClipboardWatch:
+		return "Clipboard"
+	case fieldClipboardDeny:
+		return "Ignore Sites"
+	default:
+		return ""
+	}
+}
+
+func (f configField) hint() string {
+	switch f {
+	case fieldKind:
+		return "audio, video or auto"
+	case fieldFormat:
+		return "mp3, m4a, opus, mp4, mkv, ..."
+	case fieldAudioQuality:
+		return "VBR 0 (best) – 10, or a bitrate like 192K"
+	case fieldVideoQuality:
+		return "best, or a height like 720p"
+	case fieldOutputFolder:
+		return "~ expands to your home folder"
+	case fieldOutputTemplate:
+		return "yt-dlp fields like %(title)s, %(uploader)s, %(ext)s"
+	case fieldPlaylistOutputTemplate:
+		return "also {playlist_title}, {playlist_index}, {playlist_count}"
+	case fieldRateLimit:
+		return "bytes/s like 500K or 2M, split evenly across the parallel slots; 0 for none"
+	case fieldSkipDownloaded:
+		return "yes skips videos in the download archive, no downloads them again"
+	case fieldSubtitleLangs:
+		return "languages like en,de or all; empty for none"
+	case fieldAutoSubtitles:
+		return "yes also takes auto-generated subtitles"
+	case fieldSubtitleFormat:
+		return "srt, vtt or ass"
+	case fieldEmbedSubtitles:
+		return "yes embeds them into videos, no keeps them as separate files"
+	case fieldEmbedChapters:
+		return "yes embeds chapter markers"
+	case fieldSplitChapters:
+		return "yes also saves one file per chapter, tagged as an album"
+	case fieldSponsorBlock:
+		return "off, mark (as chapters) or remove segments"
+	case fieldSponsorBlockCategories:
+		return strings.Join(sponsorBlockCategories, ", ")
+	case fieldMaxConcurrent:
+		return "downloads running at the same time"
+	case fieldClipboardWatch:
+		return "yes queues media links you copy, with the active profile"
+	case fieldClipboardDeny:
+		return "sites like github.com,google.com the clipboard watcher skips"
+	default:
+		return ""
+	}
+}
+
+// configForm is a column of text inputs editing a subset of Config. The
+// Settings screen uses it for the global config, the queue's override
+// editor for an EntryConfig.
+type configForm struct {
+	fields []configField
+	inputs []textinput.Model
+	focus  int
+}
+
+func newConfigForm(fields ...configField) configForm {
+	f := configForm{fields: fields}
+	for range fields {
+		in := textinput.New()
+		in.CharLimit = 500
+		in.SetWidth(50)
+		f.inputs = append(f.inputs, in)
+	}
+	return f
+}
+
+// zoneFormField is the click target of a form row, keyed by the form name so
+// two forms never share zone IDs.
+func zoneFormField(form string, i int) string {
+	return fmt.Sprintf("form-%s-%d", form, i)
+}
+
+// SetConfig fills every field from cfg.
+func (f *configForm) SetConfig(cfg Config) {
+	for i, field := range f.fields {
+		var v string
+		switch field {
+		case fieldKind:
+			v = string(cfg.Kind)
+		case fieldFormat:
+			v = cfg.Format
+		case fieldAudioQuality:
+			v = string(cfg.AudioQuality)
+		case fieldVideoQuality:
+			v = cfg.VideoQuality
+		case fieldOutputFolder:
+			v = cfg.OutputFolder
+		case fieldOutputTemplate:
+			v = cfg.OutputTemplate
+		case fieldPlaylistOutputTemplate:
+			v = cfg.PlaylistOutputTemplate
+		case fieldRateLimit:
+			v = string(cfg.RateLimit)
+		case fieldSkipDownloaded:
+			v = formatYesNo(cfg.SkipDownloaded)
+		case fieldSubtitleLangs:
+			v = cfg.SubtitleLangs
+		case fieldAutoSubtitles:
+			v = formatYesNo(cfg.AutoSubtitles)
+		case fieldSubtitleFormat:
+			v = cfg.SubtitleFormat
+		case fieldEmbedSubtitles:
+			v = formatYesNo(cfg.EmbedSubtitles)
+		case fieldEmbedChapters:
+			v = formatYesNo(cfg.EmbedChapters)
+		case fieldSplitChapters:
+			v = formatYesNo(cfg.SplitChapters)
+		case fieldSponsorBlock:
+			v = string(cfg.SponsorBlock)
+		case fieldSponsorBlockCategories:
+			v = cfg.SponsorBlockCategories
+		case fieldMaxConcurrent:
+			v = strconv.Itoa(cfg.MaxConcurrent)
+		case fieldClipboardWatch:
+			v = formatYesNo(cfg.ClipboardWatch)
+		case fieldClipboardDeny:
+			v = cfg.ClipboardDeny
+		}
+		f.inputs[i].SetValue(v)
+	}
+}
+
+// SetEntryConfig fills the fields an override sets and leaves the rest
+// empty, showing the inherited value as the placeholder.
+func (f *configForm) SetEntryConfig(entry EntryConfig, inherited Config) {
+	f.SetConfig(inherited)
+	for i, field := range f.fields {
+		inherited := f.inputs[i].Value()
+		if inherited == "" && field == fieldRateLimit {
+			inherited = "unlimited"
+		}
+		if inherited == "" && field == fieldSubtitleLangs {
+			inherited = "none"
+		}
+		f.inputs[i].Placeholder = inherited + " (inherited)"
+		var v *string
+		switch field {
+		case fieldKind:
+			if entry.Kind != nil {
+				s := string(*entry.Kind)
+				v = &s
+			}
+		case fieldFormat:
+			v = entry.Format
+		case fieldAudioQuality:
+			if entry.AudioQuality != nil {
+				s := string(*entry.AudioQuality)
+				v = &s
+			}
+		case fieldVideoQuality:
+			v = entry.VideoQuality
+		case fieldOutputFolder:
+			v = entry.OutputFolder
+		case fieldOutputTemplate:
+			v = entry.OutputTemplate
+		case fieldPlaylistOutputTemplate:
+			v = entry.PlaylistOutputTemplate
+		case fieldRateLimit:
+			if entry.RateLimit != nil {
+				s := string(*entry.RateLimit)
+				v = &s
+			}
+		case fieldSkipDownloaded:
+			if entry.SkipDownloaded != nil {
+				s := formatYesNo(*entry.SkipDownloaded)
+				v = &s
+			}
+		case fieldSubtitleLangs:
+			v = entry.SubtitleLangs
+		case fieldAutoSubtitles:
+			if entry.AutoSubtitles != nil {
+				s := formatYesNo(*entry.AutoSubtitles)
+				v = &s
+			}
+		case fieldSubtitleFormat:
+			v = entry.SubtitleFormat
+		case fieldEmbedSubtitles:
+			if entry.EmbedSubtitles != nil {
+				s := formatYesNo(*entry.EmbedSubtitles)
+				v = &s
+			}
+		case fieldEmbedChapters:
+			if entry.EmbedChapters != nil {
+				s := formatYesNo(*entry.EmbedChapters)
+				v = &s
+			}
+		case fieldSplitChapters:
+			if entry.SplitChapters != nil {
+				s := formatYesNo(*entry.SplitChapters)
+				v = &s
+			}
+		case fieldSponsorBlock:
+			if entry.SponsorBlock != nil {
+				s := string(*entry.SponsorBlock)
+				v = &s
+			}
+		case fieldSponsorBlockCategories:
+			v = entry.SponsorBlockCategories
+		}
+		if v != nil {
+			f.inputs[i].SetValue(*v)
+		} else {
+			f.inputs[i].SetValue("")
+		}
+	}
+}
+
+// EntryConfig returns an override holding only the non-empty fields, or
+// the first validation error.
+func (f *configForm) EntryConfig() (EntryConfig, error) {
+	var entry EntryConfig
+	for i, field := range f.fields {
+		v := strings.TrimSpace(f.inputs[i].Value())
+		if v == "" {
+			continue
+		}
+		if err := validateField(field, v); err != nil {
+			return EntryConfig{}, err
+		}
+		switch field {
+		case fieldKind:
+			kind := OutputKind(v)
+			entry.Kind = &kind
+		case fieldFormat:
+			entry.Format = &v
+		case fieldAudioQuality:
+			q := AudioQuality(strings.ToUpper(v))
+			entry.AudioQuality = &q
+		case fieldVideoQuality:
+			entry.VideoQuality = &v
+		case fieldOutputFolder:
+			folder := expandHome(v)
+			entry.OutputFolder = &folder
+		case fieldOutputTemplate:
+			entry.OutputTemplate = &v
+		case fieldPlaylistOutputTemplate:
+			entry.PlaylistOutputTemplate = &v
+		case fieldRateLimit:
+			r := RateLimit(strings.ToUpper(v))
+			entry.RateLimit = &r
+		case fieldSkipDownloaded:
+			skip := parseYesNo(v)
+			entry.SkipDownloaded = &skip
+		case fieldSubtitleLangs:
+			entry.SubtitleLangs = &v
+		case fieldAutoSubtitles:
+			auto := parseYesNo(v)
+			entry.AutoSubtitles = &auto
+		case fieldSubtitleFormat:
+			format := strings.ToLower(v)
+			entry.SubtitleFormat = &format
+		case fieldEmbedSubtitles:
+			embed := parseYesNo(v)
+			entry.EmbedSubtitles = &embed
+		case fieldEmbedChapters:
+			embed := parseYesNo(v)
+			entry.EmbedChapters = &embed
+		case fieldSplitChapters:
+			split := parseYesNo(v)
+			entry.SplitChapters = &split
+		case fieldSponsorBlock:
+			mode := SponsorBlockMode(strings.ToLower(v))
+			entry.SponsorBlock = &mode
+		case fieldSponsorBlockCategories:
+			categories := normalizeList(v)
+			entry.SponsorBlockCategories = &categories
+		}
+	}
+	return entry, nil
+}
+
+// Config returns base with the form's values applied, or the first
+// validation error.
+func (f *configForm) Config(base Config) (Config, error) {
+	cfg := base
+	for i, field := range f.fields {
+		v := strings.TrimSpace(f.inputs[i].Value())
+		if err := validateField(field, v); err != nil {
+			return base, err
+		}
+		switch field {
+		case fieldKind:
+			cfg.Kind = OutputKind(v)
+		case fieldFormat:
+			cfg.Format = v
+		case fieldAudioQuality:
+			cfg.AudioQuality = AudioQuality(strings.ToUpper(v))
+		case fieldVideoQuality:
+			cfg.VideoQuality = v
+		case fieldOutputFolder:
+			cfg.OutputFolder = expandHome(v)
+		case fieldOutputTemplate:
+			cfg.OutputTemplate = v
+		case fieldPlaylistOutputTemplate:
+			cfg.PlaylistOutputTemplate = v
+		case fieldRateLimit:
+			cfg.RateLimit = RateLimit(strings.ToUpper(v))
+		case fieldSkipDownloaded:
+			cfg.SkipDownloaded = parseYesNo(v)
+		case fieldSubtitleLangs:
+			cfg.SubtitleLangs = v
+		case fieldAutoSubtitles:
+			cfg.AutoSubtitles = parseYesNo(v)
+		case fieldSubtitleFormat:
+			cfg.SubtitleFormat = strings.ToLower(v)
+		case fieldEmbedSubtitles:
+			cfg.EmbedSubtitles = parseYesNo(v)
+		case fieldEmbedChapters:
+			cfg.EmbedChapters = parseYesNo(v)
+		case fieldSplitChapters:
+			cfg.SplitChapters = parseYesNo(v)
+		case fieldSponsorBlock:
+			cfg.SponsorBlock = SponsorBlockMode(strings.ToLower(v))
+		case fieldSponsorBlockCategories:
+			cfg.SponsorBlockCategories = normalizeList(v)
+		case fieldMaxConcurrent:
+			cfg.MaxConcurrent, _ = strconv.Atoi(v)
+		case fieldClipboardWatch:
+			cfg.ClipboardWatch = parseYesNo(v)
+		case fieldClipboardDeny:
+			cfg.ClipboardDeny = normalizeList(v)
+		}
+	}
+	return cfg, nil
+}
+
+// validateField checks a single non-empty form value.
+func validateField(field configField, v string) error {
+	switch field {
+	case fieldKind:
+		if !OutputKind(v).IsValid() {
+			return fmt.Errorf("kind must be audio, video or auto, not %q", v)
+		}
+	case fieldAudioQuality:
+		if !AudioQuality(v).IsValid() {
+			return fmt.Errorf("audio quality must be 0–10 or a bitrate like 192K, not %q", v)
+		}
+	case fieldVideoQuality:
+		if v == "best" {
+			return nil
+		}
+		if _, err := strconv.Atoi(strings.TrimSuffix(v, "p")); err != nil {
+			return fmt.Errorf("video quality must be best or a height like 720p, not %q", v)
+		}
+	case fieldOutputTemplate, fieldPlaylistOutputTemplate:
+		if strings.HasSuffix(v, "/") || strings.HasSuffix(v, string(filepath.Separator)) {
+			return fmt.Errorf("%s must end in a file name, not a folder", strings.ToLower(field.label()))
+		}
+	case fieldRateLimit:
+		if !RateLimit(v).IsValid() {
+			return fmt.Errorf("rate limit must be bytes/s like 500K or 2M, not %q", v)
+		}
+		if v == "" {
+			return nil // unlimited
+		}
+	case fieldSkipDownloaded, fieldAutoSubtitles, fieldEmbedSubtitles, fieldEmbedChapters, fieldSplitChapters,
+		fieldClipboardWatch:
+		switch strings.ToLower(v) {
+		case "yes", "no", "":
+		default:
+			return fmt.Errorf("%s must be yes or no, not %q", strings.ToLower(field.label()), v)
+		}
+	case fieldSubtitleLangs:
+		if v == "" {
+			return nil // no subtitles
+		}
+	case fieldClipboardDeny:
+		if v == "" {
+			return nil // no sites skipped
+		}
+	case fieldSubtitleFormat:
+		if v != "" && !isSubtitleFormat(strings.ToLower(v)) {
+			return fmt.Errorf("subtitle format must be srt, vtt or ass, not %q", v)
+		}
+	case fieldSponsorBlock:
+		if !SponsorBlockMode(strings.ToLower(v)).IsValid() {
+			return fmt.Errorf("sponsorblock must be off, mark or remove, not %q", v)
+		}
+	case fieldSponsorBlockCategories:
+		if !isSponsorBlockCategories(normalizeList(v)) {
+			return fmt.Errorf("segments must be a list of %s, not %q", strings.Join(sponsorBlockCategories, ", "), v)
+		}
+	case fieldMaxConcurrent:
+		if n, err := strconv.Atoi(v); err != nil || n < 1 {
+			return fmt.Errorf("parallel downloads must be a number of at least 1, not %q", v)
+		}
+	}
+	if v == "" {
+		return fmt.Errorf("%s can't be empty", strings.ToLower(field.label()))
+	}
+	return nil
+}
+
+func formatYesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func parseYesNo(s string) bool {
+	return strings.EqualFold(s, "yes")
+}
+
+// normalizeList lowercases a comma-separated list and drops the spaces
+// around its items, e.g. "Sponsor, intro" becomes "sponsor,intro".
+func normalizeList(s string) string {
+	items := strings.Split(strings.ToLower(s), ",")
+	for i, item := range items {
+		items[i] = strings.TrimSpace(item)
+	}
+	return strings.Join(items, ",")
+}
+
+// expandHome replaces a leading "~" with the user's home folder.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// Focus moves the cursor to field i.
+func (f *configForm) Focus(i int) tea.Cmd {
+	f.inputs[f.focus].Blur()
+	f.focus = max(0, min(i, len(f.inputs)-1))
+	return f.inputs[f.focus].Focus()
+}
+
+func (f *configForm) Blur() {
+	f.inputs[f.focus].Blur()
+}
+
+// Update moves between fields on up/down and feeds everything else to the
+// focused input.
+func (f *configForm) Update(msg tea.Msg) tea.Cmd {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "up":
+			return f.Focus(f.focus - 1)
+		case "down":
+			return f.Focus(f.focus + 1)
+		}
+	}
+	var cmd tea.Cmd
+	f.inputs[f.focus], cmd = f.inputs[f.focus].Update(msg)
+	return cmd
+}
+
+// View renders one labelled row per field; clicking a row focuses it.
+func (f *configForm) View(name string) string {
+	faintStyle := lipgloss.NewStyle().Faint(true)
+	cursorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Bold(true)
+
+	var s strings.Builder
+	for i, field := range f.fields {
+		pointer := "  "
+		if i == f.focus && f.inputs[i].Focused() {
+			pointer = cursorStyle.Render("› ")
+		}
+		row := fmt.Sprintf("%s%-14s %s", pointer, field.label()+":", f.inputs[i].View())
+		s.WriteString(zone.Mark(zoneFormField(name, i), row))
+		s.WriteString("\n")
+		if i == f.focus && f.inputs[i].Focused() {
+			s.WriteString(faintStyle.Render("                 " + field.hint()))
+			s.WriteString("\n")
+		}
+	}
+	return s.String()
+}
+
+// FieldAt returns the index of the row under a mouse click.
+func (f *configForm) FieldAt(name string, msg tea.MouseMsg) (int, bool) {
+	for i := range f.fields {
+		if zone.Get(zoneFormField(name, i)).InBounds(msg) {
+			return i, true
+		}
+	}
+	return 0, false
+}
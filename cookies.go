@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CookieSource is where yt-dlp reads login cookies for a site from: a
+// Netscape-format cookies file, or a browser profile given as yt-dlp's
+// --cookies-from-browser takes it, e.g. "firefox" or "chrome:Profile 1".
+type CookieSource struct {
+	File    string `yaml:"file,omitempty"`
+	Browser string `yaml:"browser,omitempty"`
+}
+
+// cookieBrowsers are the browsers yt-dlp can read cookies from.
+var cookieBrowsers = []string{"brave", "chrome", "chromium", "edge", "firefox", "opera", "safari", "vivaldi", "whale"}
+
+// CookiesFor returns the cookie source for rawURL's site: the longest
+// configured domain the host matches, else the "*" entry.
+func (c Config) CookiesFor(rawURL string) (CookieSource, bool) {
+	return siteFor(c.Cookies, rawURL)
+}
+
+// cookieArgs returns the yt-dlp flags that pass the cookies configured for
+// rawURL's site, or nothing when it has none.
+func cookieArgs(cfg Config, rawURL string) []string {
+	src, ok := cfg.CookiesFor(rawURL)
+	switch {
+	case !ok:
+		return nil
+	case src.File != "":
+		return []string{"--cookies", expandHome(src.File)}
+	case src.Browser != "":
+		return []string{"--cookies-from-browser", src.Browser}
+	}
+	return nil
+}
+
+type cookieStatus int
+
+const (
+	cookiesOK cookieStatus = iota
+	cookiesWarning
+	cookiesInvalid
+)
+
+// cookieReport is the outcome of checking one site's cookie source.
+type cookieReport struct {
+	Site   string
+	Status cookieStatus
+	Detail string // e.g. "12 cookies" or "line 4: expected 7 fields"
+}
+
+// checkCookies checks every configured cookie source, sorted by site.
+func checkCookies(cfg Config, now time.Time) []cookieReport {
+	var reports []cookieReport
+	for site, src := range cfg.Cookies {
+		report := cookieReport{Site: site}
+		report.Status, report.Detail = checkCookieSource(site, src, now)
+		reports = append(reports, report)
+	}
+	slices.SortFunc(reports, func(a, b cookieReport) int { return strings.Compare(a.Site, b.Site) })
+	return reports
+}
+
+func checkCookieSource(site string, src CookieSource, now time.Time) (cookieStatus, string) {
+	switch {
+	case src.File != "" && src.Browser != "":
+		return cookiesInvalid, "set either file or browser, not both"
+	case src.File != "":
+		return checkCookieFile(expandHome(src.File), site, now)
+	case src.Browser != "":
+		// BROWSER[+KEYRING][:PROFILE][::CONTAINER]
+		name, _, _ := strings.Cut(src.Browser, ":")
+		name, _, _ = strings.Cut(name, "+")
+		if !slices.Contains(cookieBrowsers, strings.ToLower(name)) {
+			return cookiesInvalid, fmt.Sprintf("unknown browser %q (have: %s)", name, strings.Join(cookieBrowsers, ", "))
+		}
+		return cookiesOK, "from " + src.Browser
+	}
+	return cookiesInvalid, "no file or browser set"
+}
+
+// checkCookieFile validates a Netscape cookies file, the format browser
+// extensions export and yt-dlp reads: one cookie per line as seven
+// tab-separated fields (domain, include subdomains, path, secure, expiry,
+// name, value). It warns when cookies expired or none belong to site.
+func checkCookieFile(path, site string, now time.Time) (cookieStatus, string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return cookiesInvalid, err.Error()
+	}
+	defer f.Close()
+
+	var total, expired, forSite int
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		// Browsers mark HttpOnly cookies with this prefix; other # lines
+		// are comments.
+		line, httpOnly := strings.CutPrefix(line, "#HttpOnly_")
+		if strings.TrimSpace(line) == "" || (!httpOnly && strings.HasPrefix(line, "#")) {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return cookiesInvalid, fmt.Sprintf("line %d: expected 7 tab-separated fields, got %d; is this a Netscape cookies file?", n, len(fields))
+		}
+		for _, i := range []int{1, 3} {
+			if fields[i] != "TRUE" && fields[i] != "FALSE" {
+				return cookiesInvalid, fmt.Sprintf("line %d: field %d must be TRUE or FALSE, not %q", n, i+1, fields[i])
+			}
+		}
+		expiry, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return cookiesInvalid, fmt.Sprintf("line %d: expiry must be a Unix time, not %q", n, fields[4])
+		}
+
+		total++
+		// 0 marks a session cookie, which never expires in the file.
+		if expiry != 0 && time.Unix(expiry, 0).Before(now) {
+			expired++
+		}
+		domain := strings.TrimPrefix(strings.ToLower(fields[0]), ".")
+		if site == "*" || domain == site || strings.HasSuffix(domain, "."+site) {
+			forSite++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return cookiesInvalid, err.Error()
+	}
+
+	switch {
+	case total == 0:
+		return cookiesInvalid, "no cookies in file"
+	case expired == total:
+		return cookiesWarning, fmt.Sprintf("all %d cookies expired; export them again", total)
+	case forSite == 0:
+		return cookiesWarning, fmt.Sprintf("%d cookies, none for %s", total, site)
+	case expired > 0:
+		return cookiesWarning, fmt.Sprintf("%d of %d cookies expired", expired, total)
+	}
+	return cookiesOK, fmt.Sprintf("%d cookies", total)
+}
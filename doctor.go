@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// runDoctor implements `mldy doctor`: it checks the tools mldy needs and
+// the configured cookies, printing one line per check. It returns 1 when
+// anything is broken, 0 when there are at most warnings.
+func runDoctor() int {
+	failed := false
+	ok := func(format string, args ...any) { fmt.Printf("✓ "+format+"\n", args...) }
+	warn := func(format string, args ...any) { fmt.Printf("! "+format+"\n", args...) }
+	fail := func(format string, args ...any) {
+		fmt.Printf("✗ "+format+"\n", args...)
+		failed = true
+	}
+
+	if _, err := exec.LookPath("yt-dlp"); err != nil {
+		fail("yt-dlp not found; run mldy once to install it")
+	} else if out, err := exec.Command("yt-dlp", "--version").Output(); err != nil {
+		fail("yt-dlp doesn't run: %v", err)
+	} else {
+		ok("yt-dlp %s", strings.TrimSpace(string(out)))
+	}
+
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		fail("ffmpeg not found; run mldy once to install it")
+	} else {
+		ok("ffmpeg")
+	}
+
+	switch runtime, found, recommended := detectRuntime(); {
+	case !found:
+		warn("no JavaScript runtime (deno, bun or node); some sites may fail")
+	case !recommended:
+		warn("%s is older than recommended", runtime)
+	default:
+		ok("%s", runtime)
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		fail("config: %v", err)
+	}
+	if s := config.Network.summary(); s != "" {
+		ok("network: %s", s)
+	}
+
+	reports := checkCookies(config, time.Now())
+	if len(reports) == 0 {
+		ok("cookies: none configured")
+	}
+	for _, r := range reports {
+		switch r.Status {
+		case cookiesOK:
+			ok("cookies for %s: %s", r.Site, r.Detail)
+		case cookiesWarning:
+			warn("cookies for %s: %s", r.Site, r.Detail)
+		default:
+			fail("cookies for %s: %s", r.Site, r.Detail)
+		}
+	}
+
+	if failed {
+		return 1
+	}
+	return 0
+}
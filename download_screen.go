@@ -3,8 +3,10 @@ package main
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"charm.land/lipgloss/v2"
+	zone "github.com/lrstanley/bubblezone/v2"
 )
 
 func (m Model) renderDownloadScreen() string {
@@ -13,41 +15,79 @@ func (m Model) renderDownloadScreen() string {
 	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("170"))
 	faintStyle := lipgloss.NewStyle().Faint(true)
 	boldStyle := lipgloss.NewStyle().Bold(true)
+	cursorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Bold(true)
+	pauseStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	resumeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("46"))
+	cancelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
 
-	s.WriteString(titleStyle.Render("Active Downloads"))
-	s.WriteString("\n\n")
+	active := m.backend.Queue().GetActive()
+	s.WriteString(titleStyle.Render(fmt.Sprintf("Active Downloads (%d/%d)", len(active), m.backend.Config().MaxConcurrent)))
+	if limit, _ := m.backend.Config().RateLimit.Bytes(); limit > 0 {
+		rate := fmt.Sprintf("  limit %s/s", formatBytes(limit))
+		// Every parallel slot gets the same share, used or not.
+		if slots := m.backend.Config().MaxConcurrent; slots > 1 {
+			rate += fmt.Sprintf(" (%s/s per download)", formatBytes(limit/int64(slots)))
+		}
+		s.WriteString(faintStyle.Render(rate))
+	} else {
+		s.WriteString(faintStyle.Render("  no rate limit"))
+	}
+	s.WriteString("\n")
+	if m.settingsErr != nil {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+		s.WriteString(errorStyle.Render("✗ " + m.settingsErr.Error()))
+		s.WriteString("\n")
+	}
+	s.WriteString("\n")
 
-	active := m.queue.GetActive()
-	if len(active) == 0 {
+	inProgress := m.backend.Queue().GetInProgress()
+	if len(inProgress) == 0 {
 		s.WriteString(faintStyle.Render("No active downloads"))
 	} else {
-		for _, entry := range active {
-			label := entry.DisplayTitle()
-			if entry.Playlist != nil {
-				label = fmt.Sprintf("[%s %d/%d] %s",
-					entry.Playlist.PlaylistTitle,
-					entry.Playlist.Index,
-					entry.Playlist.Total,
-					label,
-				)
+		cursor := max(0, min(m.downloadCursor, len(inProgress)-1))
+		for i, entry := range inProgress {
+			pointer := "  "
+			if i == cursor {
+				pointer = cursorStyle.Render("› ")
 			}
-			s.WriteString(fmt.Sprintf("Downloading: %s\n", label))
-			s.WriteString(m.currentProgress.ViewAs(entry.Progress / 100.0))
-			s.WriteString(fmt.Sprintf(" %.1f%%\n\n", entry.Progress))
+
+			label := entry.PlaylistLabel() + entry.DisplayTitle()
+			cancelBtn := zone.Mark(zoneCancelEntry(entry.ID), cancelStyle.Render("[✕ cancel]"))
+
+			if entry.Status == StatusPaused {
+				resumeBtn := zone.Mark(zoneResumeEntry(entry.ID), resumeStyle.Render("[▶ resume]"))
+				s.WriteString(fmt.Sprintf("%sPaused: %s  %s %s\n", pointer, label, resumeBtn, cancelBtn))
+				s.WriteString("  " + faintStyle.Render(m.currentProgress.ViewAs(entry.Progress/100.0)))
+			} else {
+				pauseBtn := zone.Mark(zonePauseEntry(entry.ID), pauseStyle.Render("[⏸ pause]"))
+				s.WriteString(fmt.Sprintf("%s%s: %s  %s %s\n", pointer, entry.Stage, label, pauseBtn, cancelBtn))
+				s.WriteString("  " + m.currentProgress.ViewAs(entry.Progress/100.0))
+			}
+			s.WriteString(fmt.Sprintf(" %.1f%%\n", entry.Progress))
+			if details := transferSummary(entry); details != "" {
+				s.WriteString("  " + faintStyle.Render(details) + "\n")
+			}
+			s.WriteString("\n")
 		}
 	}
 
 	s.WriteString("\n")
 	s.WriteString(boldStyle.Render("Overall Progress:"))
 	s.WriteString("\n")
-	totalProg := m.queue.TotalProgress()
+	totalProg := m.backend.Queue().TotalProgress()
 	s.WriteString(m.overallProgress.ViewAs(totalProg / 100.0))
 	s.WriteString(" / ")
 	s.WriteString(fmt.Sprintf("%.1f%%", totalProg))
+	if left, ok := m.backend.Queue().Remaining(); ok {
+		s.WriteString(faintStyle.Render(fmt.Sprintf("  •  about %s left", left.Round(time.Second))))
+	}
 
-	completed := len(m.queue.GetCompleted())
-	total := len(m.queue.Entries)
+	completed := len(m.backend.Queue().GetCompleted())
+	total := len(m.backend.Queue().Entries)
 	s.WriteString(fmt.Sprintf("\n\nCompleted: %d/%d", completed, total))
+	if skipped := len(m.backend.Queue().GetSkipped()); skipped > 0 {
+		s.WriteString(faintStyle.Render(fmt.Sprintf("  (%d skipped, downloaded before)", skipped)))
+	}
 
 	return s.String()
 }
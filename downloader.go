@@ -7,9 +7,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
 )
@@ -20,18 +21,59 @@ type ProgressMsg struct {
 	ID       int
 	Progress float64
 	Title    string
+
+	DownloadedBytes int64
+	TotalBytes      int64
+	Speed           float64 // bytes per second
+	ETA             time.Duration
+	Stage           DownloadStage
+
+	// ExpectedBytes is the size of all streams seen so far, so a merged
+	// download counts both video and audio.
+	ExpectedBytes int64
 }
 
 type DownloadCompleteMsg struct {
 	ID         int
 	OutputPath string
 	Error      error
+	ErrorClass ErrorClass
+
+	// OutputFiles are the per-chapter files when chapters were split.
+	OutputFiles []string
+
+	// SponsorSegments SponsorBlock segments covering SponsorSeconds were
+	// marked or removed.
+	SponsorSegments int
+	SponsorSeconds  float64
+
+	// SubtitleFiles are subtitles saved next to the output,
+	// EmbeddedSubtitles the languages embedded into it and MissingSubtitles
+	// those yt-dlp wrote that ended up nowhere.
+	SubtitleFiles     []string
+	EmbeddedSubtitles []string
+	MissingSubtitles  []string
+
+	// Stopped is set when the process was killed through Downloader.Stop;
+	// PartialFiles then lists what it left on disk (empty when discarded).
+	Stopped      bool
+	PartialFiles []string
+
+	// Skipped is set when yt-dlp found the video in the download archive.
+	Skipped bool
 }
 
 // PlaylistItem is one video entry returned by --flat-playlist -J.
 type PlaylistItem struct {
 	URL   string
 	Title string
+
+	// Duration in seconds and approximate size in bytes, zero when yt-dlp
+	// doesn't know them. Flat playlists usually carry only the duration.
+	Duration float64
+	Size     int64
+
+	ArchiveID string // see archiveID
 }
 
 // PlaylistResolvedMsg is sent after a playlist URL has been expanded into items.
@@ -46,12 +88,99 @@ type PlaylistResolvedMsg struct {
 // ---- downloader -------------------------------------------------------------
 
 type Downloader struct {
+	runtime string
+
+	// archivePath is passed as --download-archive unless an entry forces
+	// a re-download.
+	archivePath string
+
+	// mu guards globalConfig, which the Settings screen can replace while
+	// downloads are running, and the running map.
+	mu           sync.Mutex
 	globalConfig Config
-	runtime      string
+	running      map[int]*runningDownload
+}
+
+// runningDownload is the live yt-dlp process behind an active entry.
+type runningDownload struct {
+	cmd     *exec.Cmd
+	stopped bool
+	discard bool // remove partial files once the process has exited
+}
+
+func NewDownloader(config Config, runtime, archivePath string) *Downloader {
+	return &Downloader{
+		globalConfig: config,
+		runtime:      runtime,
+		archivePath:  archivePath,
+		running:      make(map[int]*runningDownload),
+	}
+}
+
+// SetConfig replaces the global config; downloads started afterwards use it.
+func (d *Downloader) SetConfig(config Config) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.globalConfig = config
+}
+
+func (d *Downloader) config() Config {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.globalConfig
 }
 
-func NewDownloader(config Config, runtime string) *Downloader {
-	return &Downloader{globalConfig: config, runtime: runtime}
+// Stop kills the yt-dlp process of an active entry. With discard set the
+// partially downloaded files are removed, otherwise they are kept so a later
+// download of the same entry continues where this one stopped.
+func (d *Downloader) Stop(id int, discard bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	rd, ok := d.running[id]
+	if !ok {
+		return fmt.Errorf("no running download for entry %d", id)
+	}
+	// A process that already exited can't be killed; its download then
+	// completes (or fails) as usual instead of being reported as stopped.
+	if err := rd.cmd.Process.Kill(); err != nil {
+		return err
+	}
+	rd.stopped = true
+	rd.discard = discard
+	return nil
+}
+
+// StopAll kills every running download, keeping partial files. Used on quit
+// so no yt-dlp process outlives mldy; the persisted queue still has those
+// entries as downloading and resumes them on the next launch.
+func (d *Downloader) StopAll() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, rd := range d.running {
+		if rd.cmd.Process.Kill() == nil {
+			rd.stopped = true
+		}
+	}
+}
+
+// IsRunning reports whether a yt-dlp process is still alive for the entry.
+func (d *Downloader) IsRunning(id int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, ok := d.running[id]
+	return ok
+}
+
+// removePartialFiles deletes download targets together with the .part and
+// .ytdl files yt-dlp keeps next to them while a download is unfinished.
+func removePartialFiles(paths []string) {
+	for _, p := range paths {
+		for _, suffix := range []string{"", ".part", ".ytdl"} {
+			os.Remove(p + suffix)
+		}
+	}
 }
 
 // baseArgs returns the args common to every yt-dlp invocation.
@@ -68,28 +197,32 @@ func (d *Downloader) baseArgs() []string {
 	return args
 }
 
-// buildArgs constructs the full yt-dlp argument list for a single video download.
-func (d *Downloader) buildArgs(cfg Config, url string) []string {
+// buildArgs constructs the full yt-dlp argument list for a single video
+// download. rateLimit is in bytes per second, 0 for unlimited.
+func (d *Downloader) buildArgs(cfg Config, entry DownloadEntry, rateLimit int64, segmentsFile string) []string {
 	args := d.baseArgs()
 	args = append(args,
 		"--no-playlist",
+		// Pick up .part files left behind by a paused download.
+		"--continue",
 		"--embed-thumbnail",
 		"--embed-metadata",
-		"-o", fmt.Sprintf("%s/%%(title)s.%%(ext)s", cfg.OutputFolder),
+		"--progress-template", progressTemplate,
+		"-o", outputTemplate(cfg, entry),
 	)
-
-	// Resolve effective kind when set to auto.
-	kind := cfg.Kind
-	if kind == KindAuto {
-		switch cfg.Format {
-		case "mp3", "m4a", "opus", "flac", "wav", "aac":
-			kind = KindAudio
-		default:
-			kind = KindVideo
-		}
+	if rateLimit > 0 {
+		args = append(args, "--limit-rate", strconv.FormatInt(rateLimit, 10))
+	}
+	if cfg.SkipDownloaded && d.archivePath != "" {
+		args = append(args, "--download-archive", d.archivePath)
 	}
+	args = append(args, cfg.Network.For(entry.URL).args()...)
+	args = append(args, cookieArgs(cfg, entry.URL)...)
+	args = append(args, subtitleArgs(cfg)...)
+	args = append(args, chapterArgs(cfg, entry)...)
+	args = append(args, sponsorBlockArgs(cfg, segmentsFile)...)
 
-	switch kind {
+	switch cfg.EffectiveKind() {
 	case KindAudio:
 		args = append(args,
 			"-x",
@@ -108,7 +241,7 @@ func (d *Downloader) buildArgs(cfg Config, url string) []string {
 		}
 	}
 
-	args = append(args, url)
+	args = append(args, entry.URL)
 	return args
 }
 
@@ -120,8 +253,10 @@ func (d *Downloader) ResolvePlaylist(url string, config EntryConfig) tea.Cmd {
 			"--flat-playlist",
 			"--no-warnings",
 			"-J", // dump JSON to stdout
-			url,
 		}
+		args = append(args, d.config().Network.For(url).args()...)
+		args = append(args, cookieArgs(d.config(), url)...)
+		args = append(args, url)
 		// Include runtime args so auth/region handling is consistent.
 		if d.runtime != "" {
 			args = append([]string{"--js-runtimes", d.runtime}, args...)
@@ -143,12 +278,18 @@ func (d *Downloader) ResolvePlaylist(url string, config EntryConfig) tea.Cmd {
 			Type    string `json:"_type"`
 			Title   string `json:"title"`
 			Entries []struct {
-				URL   string `json:"url"`
-				Title string `json:"title"`
-				ID    string `json:"id"`
+				URL      string  `json:"url"`
+				Title    string  `json:"title"`
+				ID       string  `json:"id"`
+				IEKey    string  `json:"ie_key"`
+				Duration float64 `json:"duration"`
 			} `json:"entries"`
 			// single-video fields
-			WebpageURL string `json:"webpage_url"`
+			ID             string  `json:"id"`
+			ExtractorKey   string  `json:"extractor_key"`
+			WebpageURL     string  `json:"webpage_url"`
+			Duration       float64 `json:"duration"`
+			FilesizeApprox float64 `json:"filesize_approx"`
 		}
 		if err := json.Unmarshal(out, &root); err != nil {
 			return PlaylistResolvedMsg{
@@ -168,8 +309,14 @@ func (d *Downloader) ResolvePlaylist(url string, config EntryConfig) tea.Cmd {
 			return PlaylistResolvedMsg{
 				OriginalURL:   url,
 				PlaylistTitle: "",
-				Items:         []PlaylistItem{{URL: videoURL}},
-				Config:        config,
+				Items: []PlaylistItem{{
+					URL:       videoURL,
+					Title:     root.Title,
+					Duration:  root.Duration,
+					Size:      int64(root.FilesizeApprox),
+					ArchiveID: archiveID(root.ExtractorKey, root.ID),
+				}},
+				Config: config,
 			}
 		}
 
@@ -180,7 +327,12 @@ func (d *Downloader) ResolvePlaylist(url string, config EntryConfig) tea.Cmd {
 			if !strings.HasPrefix(u, "http") && e.ID != "" {
 				u = "https://www.youtube.com/watch?v=" + e.ID
 			}
-			items = append(items, PlaylistItem{URL: u, Title: e.Title})
+			items = append(items, PlaylistItem{
+				URL:       u,
+				Title:     e.Title,
+				Duration:  e.Duration,
+				ArchiveID: archiveID(e.IEKey, e.ID),
+			})
 		}
 
 		return PlaylistResolvedMsg{
@@ -192,10 +344,11 @@ func (d *Downloader) ResolvePlaylist(url string, config EntryConfig) tea.Cmd {
 	}
 }
 
-// StartDownload runs yt-dlp for a single entry, streaming progress via progressCh.
-func (d *Downloader) StartDownload(entry *DownloadEntry, progressCh chan<- tea.Msg) tea.Cmd {
+// StartDownload runs yt-dlp for a single entry, streaming progress via
+// progressCh. rateLimit is the entry's share of the bandwidth budget.
+func (d *Downloader) StartDownload(entry *DownloadEntry, rateLimit int64, progressCh chan<- tea.Msg) tea.Cmd {
 	return func() tea.Msg {
-		finalConfig := d.globalConfig.MergeWith(entry.Config)
+		finalConfig := d.config().MergeWith(entry.Config)
 
 		if err := os.MkdirAll(finalConfig.OutputFolder, 0755); err != nil {
 			return DownloadCompleteMsg{
@@ -204,7 +357,15 @@ func (d *Downloader) StartDownload(entry *DownloadEntry, progressCh chan<- tea.M
 			}
 		}
 
-		args := d.buildArgs(finalConfig, entry.URL)
+		segmentsFile, cleanup, err := sponsorSegmentsFile(finalConfig)
+		if err != nil {
+			return DownloadCompleteMsg{
+				ID:    entry.ID,
+				Error: fmt.Errorf("failed to create SponsorBlock directory: %w", err),
+			}
+		}
+		defer cleanup()
+		args := d.buildArgs(finalConfig, *entry, rateLimit, segmentsFile)
 		cmd := exec.Command("yt-dlp", args...)
 
 		stdout, err := cmd.StdoutPipe()
@@ -220,11 +381,24 @@ func (d *Downloader) StartDownload(entry *DownloadEntry, progressCh chan<- tea.M
 			return DownloadCompleteMsg{ID: entry.ID, Error: err}
 		}
 
-		progressRe := regexp.MustCompile(`(\d+\.?\d*)%`)
+		rd := &runningDownload{cmd: cmd}
+		d.mu.Lock()
+		d.running[entry.ID] = rd
+		d.mu.Unlock()
+
 		// outputPath tracks the final file path, updated as yt-dlp prints its
 		// destination lines. For audio, the post-conversion line wins.
 		var outputPath string
 		var displayTitle string
+		// destinations collects every file yt-dlp started writing, so a
+		// stopped download knows what it left behind.
+		var destinations []string
+		var skipped bool
+		var subtitles []string
+		var chapters []string
+
+		stages := &stageTracker{audioOnly: finalConfig.EffectiveKind() == KindAudio}
+		last := ProgressMsg{ID: entry.ID}
 
 		scanner := bufio.NewScanner(stdout)
 		for scanner.Scan() {
@@ -234,6 +408,7 @@ func (d *Downloader) StartDownload(entry *DownloadEntry, progressCh chan<- tea.M
 			if strings.Contains(line, "[download] Destination:") {
 				if parts := strings.SplitN(line, "Destination:", 2); len(parts) == 2 {
 					outputPath = strings.TrimSpace(parts[1])
+					destinations = append(destinations, outputPath)
 				}
 			}
 
@@ -245,14 +420,54 @@ func (d *Downloader) StartDownload(entry *DownloadEntry, progressCh chan<- tea.M
 				}
 			}
 
+			if path, ok := strings.CutPrefix(line, subtitleWritePrefix); ok {
+				subtitles = append(subtitles, strings.TrimSpace(path))
+			}
+			if path, ok := chapterDestination(line); ok {
+				chapters = append(chapters, path)
+			}
+
+			// "[download] abc123: Title has already been recorded in the archive"
+			if strings.HasPrefix(line, "[download] ") && strings.HasSuffix(line, "has already been recorded in the archive") {
+				skipped = true
+			}
+
+			// `[Merger] Merging formats into "/path/to/file.mp4"` — the merged
+			// file replaces the separate streams.
+			if strings.HasPrefix(line, "[Merger] Merging formats into ") {
+				outputPath = strings.Trim(strings.TrimPrefix(line, "[Merger] Merging formats into "), `"`)
+			}
+
+			// Post-processors don't report progress; announce the stage
+			// change with the last byte counts so the bar moves on.
+			if stages.observe(line) && stages.stage.PostProcessing() {
+				last.Stage = stages.stage
+				last.Progress = stages.percent
+				last.Speed, last.ETA = 0, 0
+				progressCh <- last
+			}
+
 			if displayTitle == "" && outputPath != "" {
 				displayTitle = filepath.Base(outputPath)
 			}
 
-			if matches := progressRe.FindStringSubmatch(line); len(matches) > 1 {
-				if progress, err := strconv.ParseFloat(matches[1], 64); err == nil {
-					progressCh <- ProgressMsg{ID: entry.ID, Progress: progress, Title: displayTitle}
+			if p, ok := parseProgressLine(line); ok {
+				title := p.Title
+				if title == "" {
+					title = displayTitle
+				}
+				last = ProgressMsg{
+					ID:              entry.ID,
+					Progress:        stages.progress(p),
+					Title:           title,
+					DownloadedBytes: p.Downloaded,
+					TotalBytes:      p.Total,
+					Speed:           p.Speed,
+					ETA:             p.ETA,
+					Stage:           stages.stage,
+					ExpectedBytes:   stages.expectedBytes(),
 				}
+				progressCh <- last
 			}
 		}
 
@@ -263,17 +478,53 @@ func (d *Downloader) StartDownload(entry *DownloadEntry, progressCh chan<- tea.M
 			stderrBuf.WriteByte('\n')
 		}
 
-		if err := cmd.Wait(); err != nil {
+		waitErr := cmd.Wait()
+
+		d.mu.Lock()
+		delete(d.running, entry.ID)
+		stopped, discard := rd.stopped, rd.discard
+		d.mu.Unlock()
+
+		if stopped {
+			if discard {
+				removePartialFiles(destinations)
+				destinations = nil
+			}
+			return DownloadCompleteMsg{ID: entry.ID, Stopped: true, PartialFiles: destinations}
+		}
+
+		if err := waitErr; err != nil {
 			msg := fmt.Sprintf("yt-dlp error: %v", err)
 			if s := strings.TrimSpace(stderrBuf.String()); s != "" {
 				msg += "\n\n" + s
 			}
-			return DownloadCompleteMsg{ID: entry.ID, Error: fmt.Errorf("%s", msg)}
+			return DownloadCompleteMsg{
+				ID:         entry.ID,
+				Error:      fmt.Errorf("%s", msg),
+				ErrorClass: classifyError(msg),
+			}
+		}
+
+		if len(chapters) > 0 {
+			album := last.Title
+			if album == "" {
+				album = strings.TrimSuffix(filepath.Base(outputPath), filepath.Ext(outputPath))
+			}
+			tagChapterFiles(chapters, album)
 		}
 
+		subtitleFiles, embedded, missing := collectSubtitles(subtitles, finalConfig)
+		segments, seconds := readSponsorSegments(segmentsFile)
 		return DownloadCompleteMsg{
-			ID:         entry.ID,
-			OutputPath: outputPath,
+			ID:                entry.ID,
+			OutputPath:        outputPath,
+			OutputFiles:       chapters,
+			Skipped:           skipped,
+			SubtitleFiles:     subtitleFiles,
+			EmbeddedSubtitles: embedded,
+			MissingSubtitles:  missing,
+			SponsorSegments:   segments,
+			SponsorSeconds:    seconds,
 		}
 	}
 }
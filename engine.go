@@ -0,0 +1,436 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// Engine owns the download queue and schedules yt-dlp runs on it. It is
+// driven like a Bubble Tea model: Update consumes the messages produced by
+// the Downloader and returns the commands to run next. The TUI and the
+// headless `mldy get` mode share it, each providing its own event loop.
+type Engine struct {
+	config     Config
+	queue      *Queue
+	downloader *Downloader
+	history    *History
+	historyErr error
+	archive    *Archive
+
+	isRunning bool
+
+	// resolvingCount counts URLs being resolved plus those waiting in
+	// resolveQueue for one of the maxConcurrentResolves slots.
+	resolvingCount int
+	resolveQueue   []pendingResolve
+
+	progressCh chan tea.Msg
+}
+
+// maxConcurrentResolves bounds how many yt-dlp -J processes run at once when
+// a batch of URLs is imported.
+const maxConcurrentResolves = 4
+
+type pendingResolve struct {
+	url    string
+	config EntryConfig
+}
+
+func NewEngine(config Config, queue *Queue, downloader *Downloader, history *History, archive *Archive) *Engine {
+	return &Engine{
+		config:     config,
+		queue:      queue,
+		downloader: downloader,
+		history:    history,
+		archive:    archive,
+		progressCh: make(chan tea.Msg, 64),
+	}
+}
+
+// Progress is where running downloads report progress. The event loop must
+// keep draining it and pass every message on to Update.
+func (e *Engine) Progress() <-chan tea.Msg {
+	return e.progressCh
+}
+
+// Resolve expands url into queue entries once the resulting
+// PlaylistResolvedMsg is passed to Update. When all resolve slots are busy
+// the URL waits its turn and the returned command is nil.
+func (e *Engine) Resolve(url string, config EntryConfig) tea.Cmd {
+	inFlight := e.resolvingCount - len(e.resolveQueue)
+	e.resolvingCount++
+	if inFlight >= maxConcurrentResolves {
+		e.resolveQueue = append(e.resolveQueue, pendingResolve{url, config})
+		return nil
+	}
+	return e.downloader.ResolvePlaylist(url, config)
+}
+
+// ResolveAll resolves a batch of URLs with the same override.
+func (e *Engine) ResolveAll(urls []string, config EntryConfig) tea.Cmd {
+	cmds := make([]tea.Cmd, 0, len(urls))
+	for _, url := range urls {
+		cmds = append(cmds, e.Resolve(url, config))
+	}
+	return tea.Batch(cmds...)
+}
+
+// nextResolve starts the oldest waiting URL, if any, in the slot that a
+// finished resolve just freed.
+func (e *Engine) nextResolve() tea.Cmd {
+	if len(e.resolveQueue) == 0 {
+		return nil
+	}
+	next := e.resolveQueue[0]
+	e.resolveQueue = e.resolveQueue[1:]
+	return e.downloader.ResolvePlaylist(next.url, next.config)
+}
+
+// Start begins working through the queue if it isn't already.
+func (e *Engine) Start() tea.Cmd {
+	if e.isRunning || len(e.queue.GetQueued()) == 0 {
+		return nil
+	}
+	e.isRunning = true
+	return e.fillDownloadSlots()
+}
+
+// SetConfig applies a new global config to the scheduler and to every
+// download started from now on.
+func (e *Engine) SetConfig(config Config) {
+	e.config = config
+	e.downloader.SetConfig(config)
+}
+
+// Idle reports whether nothing is being resolved or downloaded.
+func (e *Engine) Idle() bool {
+	return !e.isRunning && e.resolvingCount == 0
+}
+
+// StopAll kills every running download, keeping partial files.
+func (e *Engine) StopAll() {
+	e.downloader.StopAll()
+}
+
+// Update applies a Downloader message to the queue.
+func (e *Engine) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case PlaylistResolvedMsg:
+		e.resolvingCount--
+		next := e.nextResolve()
+		if msg.Error != nil {
+			e.queue.Add(msg.OriginalURL, msg.Config)
+			id := e.queue.Entries[len(e.queue.Entries)-1].ID
+			e.queue.Update(id, func(entry *DownloadEntry) {
+				entry.Status = StatusFailed
+				entry.Error = fmt.Sprintf("playlist resolve error: %v", msg.Error)
+			})
+			return next
+		}
+		var skip *Archive
+		if e.config.MergeWith(msg.Config).SkipDownloaded {
+			skip = e.archive
+		}
+		if msg.PlaylistTitle != "" {
+			e.queue.AddPlaylistItems(msg.Items, msg.PlaylistTitle, msg.Config, skip)
+		} else if len(msg.Items) > 0 {
+			e.queue.AddItem(msg.Items[0], msg.Config, skip)
+		}
+		// Items resolved mid-run can take any free slot straight away.
+		if e.isRunning {
+			return tea.Batch(next, e.fillDownloadSlots())
+		}
+		return next
+
+	case ProgressMsg:
+		e.queue.Update(msg.ID, func(entry *DownloadEntry) {
+			entry.Progress = msg.Progress
+			entry.DownloadedBytes = msg.DownloadedBytes
+			entry.TotalBytes = msg.TotalBytes
+			entry.Speed = msg.Speed
+			entry.ETA = msg.ETA
+			entry.Stage = msg.Stage
+			if msg.ExpectedBytes > 0 {
+				entry.SizeBytes = msg.ExpectedBytes
+			}
+			if msg.Title != "" {
+				entry.Title = msg.Title
+			}
+		})
+		return nil
+
+	case DownloadCompleteMsg:
+		var retryIn time.Duration
+		e.queue.Update(msg.ID, func(entry *DownloadEntry) {
+			if msg.Stopped {
+				// Paused or canceled by the user, who already set the status.
+				// A paused entry may have been canceled while it was exiting.
+				if entry.Status == StatusCanceled {
+					removePartialFiles(msg.PartialFiles)
+				} else {
+					entry.PartialFiles = msg.PartialFiles
+				}
+				return
+			}
+
+			attempt := DownloadAttempt{StartTime: entry.StartTime, EndTime: time.Now()}
+			if msg.Error != nil {
+				attempt.Error = msg.Error.Error()
+				attempt.Class = msg.ErrorClass
+			}
+			entry.Attempts = append(entry.Attempts, attempt)
+			entry.EndTime = attempt.EndTime
+
+			switch {
+			case msg.Error != nil && e.config.Retry.ShouldRetry(msg.ErrorClass, len(entry.Attempts)):
+				retryIn = e.config.Retry.Backoff(len(entry.Attempts))
+				entry.Status = StatusQueued
+				entry.Error = attempt.Error
+				entry.RetryAt = time.Now().Add(retryIn)
+			case msg.Error != nil:
+				entry.Status = StatusFailed
+				entry.Error = attempt.Error
+			case msg.Skipped:
+				entry.Status = StatusSkipped
+				entry.Error = ""
+			default:
+				entry.Status = StatusCompleted
+				entry.Error = ""
+				entry.OutputPath = msg.OutputPath
+				entry.OutputFiles = msg.OutputFiles
+				entry.SubtitleFiles = msg.SubtitleFiles
+				entry.EmbeddedSubtitles = msg.EmbeddedSubtitles
+				entry.MissingSubtitles = msg.MissingSubtitles
+				entry.SponsorSegments = msg.SponsorSegments
+				entry.SponsorSeconds = msg.SponsorSeconds
+			}
+		})
+
+		var cmds []tea.Cmd
+		if entry := e.queue.GetByID(msg.ID); entry != nil && !msg.Stopped {
+			switch entry.Status {
+			case StatusQueued:
+				id := msg.ID
+				cmds = append(cmds, tea.Tick(retryIn, func(time.Time) tea.Msg { return RetryDueMsg{ID: id} }))
+			case StatusSkipped:
+				// Nothing was downloaded, so there's nothing to remember.
+			case StatusCompleted:
+				e.recordHistory(msg.ID)
+				// yt-dlp only records downloads that skip archived videos, so
+				// forced ones are added here to keep the archive matching the disk.
+				if err := e.archive.Add(entry.ArchiveID); err != nil && e.historyErr == nil {
+					e.historyErr = fmt.Errorf("download archive: %w", err)
+				}
+			default:
+				e.recordHistory(msg.ID)
+			}
+		}
+		if e.isRunning {
+			cmds = append(cmds, e.fillDownloadSlots())
+		}
+		return tea.Batch(cmds...)
+
+	case RetryDueMsg:
+		e.queue.Update(msg.ID, func(entry *DownloadEntry) { entry.RetryAt = time.Time{} })
+		if e.isRunning {
+			return e.fillDownloadSlots()
+		}
+		return nil
+	}
+	return nil
+}
+
+// fillDownloadSlots starts queued entries until Config.MaxConcurrent downloads
+// are running. Entries waiting out a retry backoff are skipped but keep the
+// run alive; it ends once nothing is active and nothing is left queued.
+func (e *Engine) fillDownloadSlots() tea.Cmd {
+	active := len(e.queue.GetActive())
+	queued := e.queue.GetQueued()
+	if active == 0 && len(queued) == 0 {
+		e.isRunning = false
+		return nil
+	}
+
+	var starting []DownloadEntry
+	for _, entry := range queued {
+		if active+len(starting) >= e.config.MaxConcurrent {
+			break
+		}
+		if time.Now().Before(entry.RetryAt) {
+			continue
+		}
+		starting = append(starting, entry)
+	}
+
+	var cmds []tea.Cmd
+	for _, entry := range starting {
+		e.queue.Update(entry.ID, func(entry *DownloadEntry) {
+			entry.Status = StatusDownloading
+			entry.StartTime = time.Now()
+		})
+		// Hand the downloader a copy: the queue slice may be reallocated while
+		// the download goroutine is still reading from it.
+		snapshot := *e.queue.GetByID(entry.ID)
+		limit := e.rateLimit(snapshot)
+		cmds = append(cmds, e.downloader.StartDownload(&snapshot, limit, e.progressCh))
+	}
+	return tea.Batch(cmds...)
+}
+
+// rateLimit is the bandwidth an entry gets when parallel downloads share
+// the global budget, capped further by the entry's own limit. Every parallel
+// slot gets the same fixed share, so downloads that join later never push
+// the total over the budget; fewer running downloads leave some unused.
+func (e *Engine) rateLimit(entry DownloadEntry) int64 {
+	limit, _ := e.config.RateLimit.Bytes()
+	if limit > 0 {
+		limit /= int64(max(1, e.config.MaxConcurrent))
+	}
+	if entry.Config.RateLimit != nil {
+		if own, _ := entry.Config.RateLimit.Bytes(); own > 0 && (limit == 0 || own < limit) {
+			limit = own
+		}
+	}
+	return limit
+}
+
+// Redownload queues a skipped entry again, bypassing the archive.
+func (e *Engine) Redownload(id int) tea.Cmd {
+	entry := e.queue.GetByID(id)
+	if entry == nil || entry.Status != StatusSkipped {
+		return nil
+	}
+	e.queue.Update(id, func(entry *DownloadEntry) {
+		skip := false
+		entry.Config.SkipDownloaded = &skip
+		entry.Status = StatusQueued
+	})
+	if e.isRunning {
+		return e.fillDownloadSlots()
+	}
+	return nil
+}
+
+// Retry queues a failed or canceled entry again with a fresh set of
+// attempts.
+func (e *Engine) Retry(id int) tea.Cmd {
+	entry := e.queue.GetByID(id)
+	if entry == nil || (entry.Status != StatusFailed && entry.Status != StatusCanceled) {
+		return nil
+	}
+	e.queue.Update(id, func(entry *DownloadEntry) {
+		entry.Status = StatusQueued
+		entry.Error = ""
+		entry.Attempts = nil
+		entry.RetryAt = time.Time{}
+	})
+	if e.isRunning {
+		return e.fillDownloadSlots()
+	}
+	return nil
+}
+
+// Pause kills an active download but keeps its partial files around.
+func (e *Engine) Pause(id int) tea.Cmd {
+	entry := e.queue.GetByID(id)
+	if entry == nil || entry.Status != StatusDownloading {
+		return nil
+	}
+	if err := e.downloader.Stop(id, false); err != nil {
+		return nil
+	}
+	e.queue.Update(id, func(entry *DownloadEntry) { entry.Status = StatusPaused })
+	return e.fillDownloadSlots()
+}
+
+// Resume puts a paused entry back in the queue; yt-dlp continues from the
+// .part file when the scheduler picks it up again.
+func (e *Engine) Resume(id int) tea.Cmd {
+	entry := e.queue.GetByID(id)
+	// The killed process may not have exited yet; resuming now would race it
+	// for the same .part file.
+	if entry == nil || entry.Status != StatusPaused || e.downloader.IsRunning(id) {
+		return nil
+	}
+	e.queue.Update(id, func(entry *DownloadEntry) { entry.Status = StatusQueued })
+	e.isRunning = true
+	return e.fillDownloadSlots()
+}
+
+// Cancel stops an active or paused entry for good and removes whatever it
+// had downloaded so far.
+func (e *Engine) Cancel(id int) tea.Cmd {
+	entry := e.queue.GetByID(id)
+	if entry == nil {
+		return nil
+	}
+	switch entry.Status {
+	case StatusDownloading:
+		if err := e.downloader.Stop(id, true); err != nil {
+			return nil
+		}
+	case StatusPaused:
+		removePartialFiles(entry.PartialFiles)
+	default:
+		return nil
+	}
+	e.queue.Update(id, func(entry *DownloadEntry) {
+		entry.Status = StatusCanceled
+		entry.PartialFiles = nil
+		entry.EndTime = time.Now()
+	})
+	e.recordHistory(id)
+	if e.isRunning {
+		return e.fillDownloadSlots()
+	}
+	return nil
+}
+
+// recordHistory appends a finished entry to the durable history.
+func (e *Engine) recordHistory(id int) {
+	entry := e.queue.GetByID(id)
+	if entry == nil {
+		return
+	}
+
+	rec := HistoryRecord{
+		URL:         entry.URL,
+		Title:       entry.Title,
+		Status:      entry.Status,
+		Error:       entry.Error,
+		OutputPath:  entry.OutputPath,
+		OutputFiles: entry.OutputFiles,
+		Playlist:    entry.Playlist,
+		Attempts:    len(entry.Attempts),
+		Config:      e.config.MergeWith(entry.Config),
+		StartTime:   entry.StartTime,
+		EndTime:     entry.EndTime,
+
+		SubtitleFiles:     entry.SubtitleFiles,
+		EmbeddedSubtitles: entry.EmbeddedSubtitles,
+		MissingSubtitles:  entry.MissingSubtitles,
+		SponsorSegments:   entry.SponsorSegments,
+		SponsorSeconds:    entry.SponsorSeconds,
+	}
+	// Profiles, cookie sources and network settings aren't part of what
+	// the download produced, and cookie paths and proxy passwords don't
+	// belong in the history file.
+	rec.Config.Profiles = nil
+	rec.Config.Cookies = nil
+	rec.Config.Network = NetworkConfig{}
+	// Split chapters are the size of the whole file again, so they replace
+	// OutputPath in the total rather than adding to it.
+	files := entry.OutputFiles
+	if len(files) == 0 {
+		files = []string{entry.OutputPath}
+	}
+	for _, path := range files {
+		if info, err := os.Stat(path); path != "" && err == nil {
+			rec.Size += info.Size()
+		}
+	}
+	e.historyErr = e.history.Append(rec)
+}
@@ -12,26 +12,55 @@ func (m Model) renderFooter() string {
 
 	switch m.screen {
 	case ScreenInput:
+		if m.override != nil {
+			helps = append(helps, "↑/↓: field  •  enter: apply  •  esc: cancel  •  ctrl+r: clear all")
+			break
+		}
 		helps = append(helps, "enter: add URL")
-		if m.resolvingCount > 0 {
+		if len(m.backend.Config().Profiles) > 0 {
+			helps = append(helps, "ctrl+p: profile")
+		}
+		if m.redownload {
+			helps = append(helps, "ctrl+f: skip downloaded")
+		} else {
+			helps = append(helps, "ctrl+f: force re-download")
+		}
+		if len(m.backend.Queue().GetQueued()) > 0 {
+			helps = append(helps, "↑/↓ + ctrl+e: edit entry  •  ctrl+g: edit playlist")
+		}
+		if m.backend.Resolving() > 0 {
 			helps = append(helps, "resolving...")
-		} else if m.isRunning {
+		} else if m.backend.Running() {
 			helps = append(helps, "ctrl+d: downloading...")
-		} else if len(m.queue.GetQueued()) > 0 {
+		} else if len(m.backend.Queue().GetQueued()) > 0 {
 			helps = append(helps, "ctrl+d: start  •  backspace: remove last")
 		}
 	case ScreenDownload:
-		if m.isRunning {
+		if len(m.backend.Queue().GetInProgress()) > 0 {
+			helps = append(helps, "↑/↓: select  •  p: pause  •  r: resume  •  x: cancel")
+		}
+		helps = append(helps, "+/-: rate limit")
+		if m.backend.Running() {
 			helps = append(helps, "downloading...")
-		} else if len(m.queue.GetQueued()) > 0 {
+		} else if len(m.backend.Queue().GetQueued()) > 0 {
 			helps = append(helps, "ctrl+d: start downloads")
 		}
 	case ScreenHistory:
-		if len(m.queue.GetCompleted()) == 0 {
+		if m.historySearch.Focused() {
+			helps = append(helps, "enter/esc: done searching")
+		} else if m.backend.History().Len() == 0 {
 			helps = append(helps, "no history yet")
+		} else {
+			helps = append(helps, "/: search  •  esc: clear  •  f: filter  •  ←/→: page")
 		}
+	case ScreenSettings:
+		helps = append(helps, "↑/↓: field  •  enter: save  •  esc: discard")
 	}
 
-	helps = append(helps, "q: quit")
+	if m.screen == ScreenSettings || (m.screen == ScreenInput && m.override != nil) {
+		helps = append(helps, "ctrl+c: quit")
+	} else {
+		helps = append(helps, "q: quit")
+	}
 	return helpStyle.Render(strings.Join(helps, " • "))
 }
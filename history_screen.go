@@ -3,10 +3,15 @@ package main
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"charm.land/lipgloss/v2"
+	zone "github.com/lrstanley/bubblezone/v2"
 )
 
+// maxListedChapters caps the chapter files shown per history record.
+const maxListedChapters = 5
+
 func (m Model) renderHistoryScreen() string {
 	var s strings.Builder
 
@@ -16,45 +21,117 @@ func (m Model) renderHistoryScreen() string {
 	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
 	errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("208"))
 	playlistStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("69")).Bold(true)
+	filterStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Bold(true)
+
+	s.WriteString(titleStyle.Render(fmt.Sprintf("Download History (%d)", m.backend.History().Len())))
+	s.WriteString("\n\n")
 
-	s.WriteString(titleStyle.Render("Download History"))
+	s.WriteString(zone.Mark(zoneHistorySearch, "Search: "+m.historySearch.View()))
+	s.WriteString("  ")
+	s.WriteString(zone.Mark(zoneHistoryFilter, filterStyle.Render(fmt.Sprintf("[Filter: %s]", m.historyFilter))))
 	s.WriteString("\n\n")
 
-	completed := m.queue.GetCompleted()
-	if len(completed) == 0 {
-		s.WriteString(faintStyle.Render("No completed downloads"))
+	if m.backend.HistoryErr() != nil {
+		s.WriteString(errorStyle.Render(fmt.Sprintf("⚠ Could not save history: %v", m.backend.HistoryErr())))
+		s.WriteString("\n\n")
+	}
+
+	records := m.backend.History().Search(m.historySearch.Value(), m.historyFilter)
+	if len(records) == 0 {
+		if m.backend.History().Len() == 0 {
+			s.WriteString(faintStyle.Render("No completed downloads"))
+		} else {
+			s.WriteString(faintStyle.Render("No downloads match"))
+		}
 		return s.String()
 	}
 
+	pageSize := m.historyPageSize()
+	pages := m.historyPageCount()
+	page := max(0, min(m.historyPage, pages-1))
+	start := page * pageSize
+	end := min(start+pageSize, len(records))
+
 	lastPlaylist := ""
-	for _, entry := range completed {
-		if entry.Playlist != nil && entry.Playlist.PlaylistTitle != lastPlaylist {
-			lastPlaylist = entry.Playlist.PlaylistTitle
+	for _, rec := range records[start:end] {
+		if rec.Playlist != nil && rec.Playlist.PlaylistTitle != lastPlaylist {
+			lastPlaylist = rec.Playlist.PlaylistTitle
 			s.WriteString(playlistStyle.Render("▶ "+lastPlaylist) + "\n")
-		} else if entry.Playlist == nil {
+		} else if rec.Playlist == nil {
 			lastPlaylist = ""
 		}
 
 		indent := ""
-		if entry.Playlist != nil {
+		if rec.Playlist != nil {
 			indent = "  "
 		}
 
 		icon := successStyle.Render("✓")
-		if entry.Status == StatusFailed {
+		switch rec.Status {
+		case StatusFailed:
 			icon = failStyle.Render("✗")
+		case StatusCanceled:
+			icon = faintStyle.Render("⊘")
+		}
+
+		details := rec.EndTime.Local().Format("2006-01-02 15:04")
+		if rec.Size > 0 {
+			details += " • " + formatBytes(rec.Size)
+		}
+		if rec.Attempts > 1 {
+			details += fmt.Sprintf(" • %d attempts", rec.Attempts)
 		}
-		s.WriteString(fmt.Sprintf("%s%s %s\n", indent, icon, entry.DisplayTitle()))
+		s.WriteString(fmt.Sprintf("%s%s %s  %s\n", indent, icon, rec.DisplayTitle(), faintStyle.Render(details)))
 
-		if entry.Status == StatusFailed && entry.Error != "" {
-			for _, line := range strings.Split(entry.Error, "\n") {
+		if rec.Status == StatusFailed && rec.Error != "" {
+			for _, line := range strings.Split(rec.Error, "\n") {
 				s.WriteString(indent + "  " + errorStyle.Render(line) + "\n")
 			}
-		} else if entry.OutputPath != "" {
-			s.WriteString(fmt.Sprintf("%s  Saved to: %s\n", indent, entry.OutputPath))
+		} else if rec.OutputPath != "" {
+			s.WriteString(fmt.Sprintf("%s  Saved to: %s\n", indent, rec.OutputPath))
+		}
+		for i, file := range rec.OutputFiles {
+			if i == maxListedChapters && len(rec.OutputFiles) > maxListedChapters+1 {
+				s.WriteString(faintStyle.Render(fmt.Sprintf("%s  … and %d more chapters", indent, len(rec.OutputFiles)-i)))
+				s.WriteString("\n")
+				break
+			}
+			s.WriteString(faintStyle.Render(fmt.Sprintf("%s  Chapter: %s", indent, file)))
+			s.WriteString("\n")
+		}
+		if len(rec.EmbeddedSubtitles) > 0 {
+			s.WriteString(faintStyle.Render(fmt.Sprintf("%s  Subtitles embedded: %s", indent, strings.Join(rec.EmbeddedSubtitles, ", "))))
+			s.WriteString("\n")
+		}
+		if len(rec.MissingSubtitles) > 0 {
+			s.WriteString(indent + "  " + errorStyle.Render("Subtitles missing: "+strings.Join(rec.MissingSubtitles, ", ")))
+			s.WriteString("\n")
+		}
+		if rec.SponsorSegments > 0 {
+			verb := "marked"
+			if rec.Config.SponsorBlock == SponsorBlockRemove {
+				verb = "removed"
+			}
+			seconds := time.Duration(rec.SponsorSeconds * float64(time.Second)).Round(time.Second)
+			s.WriteString(faintStyle.Render(fmt.Sprintf("%s  SponsorBlock: %s %d segments (%s)", indent, verb, rec.SponsorSegments, seconds)))
+			s.WriteString("\n")
+		}
+		for _, sub := range rec.SubtitleFiles {
+			s.WriteString(faintStyle.Render(fmt.Sprintf("%s  Subtitles: %s", indent, sub)))
+			s.WriteString("\n")
 		}
 		s.WriteString("\n")
 	}
 
+	prev := faintStyle.Render("‹ prev")
+	if page > 0 {
+		prev = zone.Mark(zoneHistoryPrev, "‹ prev")
+	}
+	next := faintStyle.Render("next ›")
+	if page < pages-1 {
+		next = zone.Mark(zoneHistoryNext, "next ›")
+	}
+	s.WriteString(fmt.Sprintf("%s  Page %d/%d (%d matching)  %s", prev, page+1, pages, len(records), next))
+
 	return s.String()
 }
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+// HistoryRecord is one finished download as kept in the history file.
+type HistoryRecord struct {
+	URL         string         `yaml:"url"`
+	Title       string         `yaml:"title,omitempty"`
+	Status      DownloadStatus `yaml:"status"`
+	Error       string         `yaml:"error,omitempty"`
+	OutputPath  string         `yaml:"output_path,omitempty"`
+	OutputFiles []string       `yaml:"output_files,omitempty"` // per-chapter files
+	Size        int64          `yaml:"size,omitempty"`         // bytes, 0 when unknown
+	Playlist    *PlaylistMeta  `yaml:"playlist,omitempty"`
+	Attempts    int            `yaml:"attempts,omitempty"`
+
+	SubtitleFiles     []string `yaml:"subtitle_files,omitempty"`
+	EmbeddedSubtitles []string `yaml:"embedded_subtitles,omitempty"`
+	MissingSubtitles  []string `yaml:"missing_subtitles,omitempty"`
+
+	// SponsorBlock segments marked or removed, per Config.SponsorBlock.
+	SponsorSegments int     `yaml:"sponsor_segments,omitempty"`
+	SponsorSeconds  float64 `yaml:"sponsor_seconds,omitempty"`
+
+	// Config is the effective configuration the download ran with.
+	Config Config `yaml:"config"`
+
+	StartTime time.Time `yaml:"start_time"`
+	EndTime   time.Time `yaml:"end_time"`
+}
+
+// DisplayTitle returns the best available label for UI display.
+func (r *HistoryRecord) DisplayTitle() string {
+	if r.Title != "" {
+		return r.Title
+	}
+	return r.URL
+}
+
+// HistoryFilter narrows history searches down to a set of statuses.
+type HistoryFilter int
+
+const (
+	FilterAll HistoryFilter = iota
+	FilterCompleted
+	FilterFailed
+	FilterCanceled
+	historyFilterCount
+)
+
+func (f HistoryFilter) String() string {
+	switch f {
+	case FilterCompleted:
+		return "Completed"
+	case FilterFailed:
+		return "Failed"
+	case FilterCanceled:
+		return "Canceled"
+	default:
+		return "All"
+	}
+}
+
+func (f HistoryFilter) Matches(s DownloadStatus) bool {
+	switch f {
+	case FilterCompleted:
+		return s == StatusCompleted
+	case FilterFailed:
+		return s == StatusFailed
+	case FilterCanceled:
+		return s == StatusCanceled
+	default:
+		return true
+	}
+}
+
+// History is the durable record of every download that ever finished,
+// stored as one JSON object per line so appending never rewrites the file.
+type History struct {
+	path    string
+	records []HistoryRecord // oldest first, as in the file
+}
+
+// historyPath returns ~/.config/mldy/history.jsonl.
+func historyPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.jsonl"), nil
+}
+
+// OpenHistory loads the history file at path. Lines that fail to parse are
+// skipped so one corrupt record doesn't hide the rest.
+func OpenHistory(path string) (*History, error) {
+	h := &History{path: path}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return h, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var rec HistoryRecord
+		if err := yaml.Unmarshal(line, &rec); err != nil {
+			continue
+		}
+		h.records = append(h.records, rec)
+	}
+	return h, scanner.Err()
+}
+
+// Append adds a record in memory and, if the history has a path, on disk.
+func (h *History) Append(rec HistoryRecord) error {
+	h.records = append(h.records, rec)
+	if h.path == "" {
+		return nil
+	}
+
+	// yaml.JSON keeps the field names from the yaml tags shared with Config.
+	data, err := yaml.MarshalWithOptions(rec, yaml.JSON())
+	if err != nil {
+		return err
+	}
+	data = append(bytes.TrimSpace(data), '\n')
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(data)
+	return err
+}
+
+func (h *History) Len() int {
+	return len(h.records)
+}
+
+// Search returns matching records, newest first. query is matched
+// case-insensitively against title, URL, output path, playlist and error.
+func (h *History) Search(query string, filter HistoryFilter) []HistoryRecord {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	var out []HistoryRecord
+	for i := len(h.records) - 1; i >= 0; i-- {
+		rec := h.records[i]
+		if !filter.Matches(rec.Status) {
+			continue
+		}
+		if query != "" && !rec.matches(query) {
+			continue
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+func (r *HistoryRecord) matches(query string) bool {
+	fields := []string{r.Title, r.URL, r.OutputPath, r.Error}
+	if r.Playlist != nil {
+		fields = append(fields, r.Playlist.PlaylistTitle)
+	}
+	for _, f := range fields {
+		if strings.Contains(strings.ToLower(f), query) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// parseURLList reads URLs from r: whitespace separates them and a '#' at
+// the start of a word comments out the rest of the line, so a URL's own
+// #fragment is kept. Used for -a files, piped stdin and multi-line pastes
+// alike.
+func parseURLList(r io.Reader) ([]string, error) {
+	var urls []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		for _, field := range strings.Fields(scanner.Text()) {
+			if strings.HasPrefix(field, "#") {
+				break
+			}
+			urls = append(urls, field)
+		}
+	}
+	return urls, scanner.Err()
+}
+
+// readURLFile parses a URL list file; "-" means stdin.
+func readURLFile(path string) ([]string, error) {
+	if path == "-" {
+		return parseURLList(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseURLList(f)
+}
+
+// stdinIsPiped reports whether stdin is a pipe or file rather than a terminal.
+func stdinIsPiped() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
+
+// collectURLs gathers the URLs given on the command line, from -a files
+// and, when neither was used, from piped stdin.
+func collectURLs(args, files []string) ([]string, error) {
+	urls := append([]string(nil), args...)
+	for _, path := range files {
+		fileURLs, err := readURLFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+		urls = append(urls, fileURLs...)
+	}
+	// Only fall back to stdin when nothing else was asked for, so scripts
+	// that pass URLs explicitly never block on an open stdin.
+	if len(args) == 0 && len(files) == 0 && stdinIsPiped() {
+		return parseURLList(os.Stdin)
+	}
+	return urls, nil
+}
+
+// fileList is a repeatable string flag, as in `-a one.txt -a two.txt`.
+type fileList []string
+
+func (f *fileList) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *fileList) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
@@ -3,8 +3,10 @@ package main
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
 	zone "github.com/lrstanley/bubblezone/v2"
 )
 
@@ -18,26 +20,71 @@ func (m Model) renderInputScreen() string {
 	s.WriteString(titleStyle.Render("Add URLs to Queue"))
 	s.WriteString("\n\n")
 	s.WriteString(m.urlInput.View())
-	s.WriteString("\n\n")
+	s.WriteString("\n")
+	if len(m.backend.Config().Profiles) > 0 {
+		profileStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
+		name, summary := "none", m.backend.Config().Summary()
+		if m.profile != "" {
+			name = m.profile
+			summary = m.backend.Config().MergeWith(m.activeProfile()).Summary()
+		}
+		profileBtn := zone.Mark(zoneProfileBtn, profileStyle.Render("‹"+name+"›"))
+		s.WriteString(fmt.Sprintf("  Profile: %s%s", profileBtn, faintStyle.Render("  "+summary)))
+		s.WriteString("\n")
+	}
+	if m.redownload {
+		forceStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
+		forceBtn := zone.Mark(zoneRedownloadBtn, forceStyle.Render("‹re-download›"))
+		s.WriteString(fmt.Sprintf("  Archive: %s%s\n", forceBtn, faintStyle.Render("  videos downloaded before are fetched again")))
+	}
+	if m.backend.Config().ClipboardWatch {
+		if m.clipboardErr != nil {
+			warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("208"))
+			s.WriteString(warnStyle.Render(fmt.Sprintf("  ⚠ Clipboard watcher: %v", m.clipboardErr)))
+		} else {
+			s.WriteString(faintStyle.Render("  📋 Watching the clipboard for links"))
+		}
+		s.WriteString("\n")
+	}
+	s.WriteString("\n")
+
+	if m.toast != "" {
+		toastStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("46")).Bold(true)
+		s.WriteString(toastStyle.Render(ansi.Truncate(m.toast, max(20, m.width-2), "…")))
+		s.WriteString("\n\n")
+	}
 
-	if m.resolvingCount > 0 {
-		s.WriteString(faintStyle.Render(fmt.Sprintf("⟳ Resolving %d URL(s)...", m.resolvingCount)))
+	if err := m.backend.Queue().SaveError(); err != nil {
+		warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("208"))
+		s.WriteString(warnStyle.Render(fmt.Sprintf("⚠ Could not save queue: %v", err)))
 		s.WriteString("\n\n")
 	}
 
-	queued := m.queue.GetQueued()
-	if len(queued) > 0 {
+	if m.backend.Resolving() > 0 {
+		s.WriteString(faintStyle.Render(fmt.Sprintf("⟳ Resolving %d URL(s)...", m.backend.Resolving())))
+		s.WriteString("\n\n")
+	}
+
+	queued := m.backend.Queue().GetQueued()
+	if m.override != nil {
+		s.WriteString(m.renderOverrideEditor())
+	} else if len(queued) > 0 {
 		s.WriteString(boldStyle.Render(fmt.Sprintf("Queued (%d):", len(queued))))
 		s.WriteString("\n")
 
 		playlistStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("69")).Bold(true)
 		removeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Faint(true)
+		editStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("69")).Faint(true)
+		cursorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Bold(true)
+		overrideStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
 		lastPlaylist := ""
+		cursor := max(0, min(m.queueCursor, len(queued)-1))
 
 		for i, entry := range queued {
 			if entry.Playlist != nil && entry.Playlist.PlaylistTitle != lastPlaylist {
 				lastPlaylist = entry.Playlist.PlaylistTitle
-				s.WriteString(fmt.Sprintf("  %s\n", playlistStyle.Render("▶ "+lastPlaylist)))
+				editGroupBtn := zone.Mark(zoneEditPlaylist(entry.ID), editStyle.Render(" ⚙"))
+				s.WriteString(fmt.Sprintf("  %s%s\n", playlistStyle.Render("▶ "+lastPlaylist), editGroupBtn))
 			} else if entry.Playlist == nil {
 				lastPlaylist = ""
 			}
@@ -46,15 +93,36 @@ func (m Model) renderInputScreen() string {
 			if entry.Playlist != nil {
 				indent = "    "
 			}
+			if i == cursor {
+				indent = cursorStyle.Render("›") + indent[1:]
+			}
 
 			label := entry.DisplayTitle()
 			if entry.Playlist != nil {
 				label = fmt.Sprintf("%d/%d  %s", entry.Playlist.Index, entry.Playlist.Total, label)
 			}
 
-			// ✕ button, individually zoned per entry ID.
+			if wait := time.Until(entry.RetryAt); wait > 0 {
+				label += faintStyle.Render(fmt.Sprintf("  ↻ attempt %d/%d in %s",
+					len(entry.Attempts)+1, m.backend.Config().Retry.MaxAttempts, wait.Round(time.Second)))
+			}
+
+			// Effective settings, highlighted when the entry overrides them.
+			effective := m.backend.Config().MergeWith(entry.Config)
+			settings := effective.Summary()
+			if entry.Config.OutputFolder != nil {
+				settings += " → " + effective.OutputFolder
+			}
+			if entry.Config.IsZero() {
+				settings = faintStyle.Render("  " + settings)
+			} else {
+				settings = overrideStyle.Render("  * " + settings)
+			}
+
+			// ⚙ and ✕ buttons, individually zoned per entry ID.
+			editBtn := zone.Mark(zoneEditEntry(entry.ID), editStyle.Render(" ⚙"))
 			removeBtn := zone.Mark(zoneRemoveEntry(entry.ID), removeStyle.Render(" ✕"))
-			s.WriteString(fmt.Sprintf("%s%d. %s%s\n", indent, i+1, label, removeBtn))
+			s.WriteString(fmt.Sprintf("%s%d. %s%s%s%s\n", indent, i+1, label, settings, editBtn, removeBtn))
 		}
 
 		s.WriteString("\n")
@@ -75,11 +143,11 @@ func (m Model) renderInputScreen() string {
 
 		removeBtn := zone.Mark(zoneRemoveBtn, removeBtnStyle.Render("✕ Remove last"))
 
-		canStart := !m.isRunning && m.resolvingCount == 0
+		canStart := !m.backend.Running() && m.backend.Resolving() == 0
 		var startBtn string
 		if canStart {
 			startBtn = zone.Mark(zoneStartBtn, startBtnStyle.Render("▶ Start downloads"))
-		} else if m.isRunning {
+		} else if m.backend.Running() {
 			startBtn = disabledBtnStyle.Render("⟳ Downloading...")
 		} else {
 			startBtn = disabledBtnStyle.Render("▶ Start downloads")
@@ -87,18 +155,29 @@ func (m Model) renderInputScreen() string {
 
 		s.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, "  ", removeBtn, "  ", startBtn))
 		s.WriteString("\n")
-	} else if m.resolvingCount == 0 {
+	} else if m.backend.Resolving() == 0 {
 		s.WriteString(faintStyle.Render("No items in queue"))
 	}
 
+	if skipped := m.backend.Queue().GetSkipped(); len(skipped) > 0 && m.override == nil {
+		redownloadStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("69")).Faint(true)
+		s.WriteString("\n")
+		s.WriteString(boldStyle.Render(fmt.Sprintf("Skipped, downloaded before (%d):", len(skipped))))
+		s.WriteString("\n")
+		for _, entry := range skipped {
+			btn := zone.Mark(zoneRedownloadEntry(entry.ID), redownloadStyle.Render(" ↻ download again"))
+			s.WriteString(faintStyle.Render("  ⤼ "+entry.PlaylistLabel()+entry.DisplayTitle()) + btn + "\n")
+		}
+	}
+
 	s.WriteString("\n\n")
-	s.WriteString(boldStyle.Render("Current Config:"))
+	s.WriteString(boldStyle.Render("Current Config:") + faintStyle.Render(" (edit in Settings)"))
 	s.WriteString("\n")
-	s.WriteString(fmt.Sprintf("  Kind:          %s\n", m.config.Kind))
-	s.WriteString(fmt.Sprintf("  Format:        %s\n", m.config.Format))
-	s.WriteString(fmt.Sprintf("  Audio Quality: %s\n", m.config.AudioQuality))
-	s.WriteString(fmt.Sprintf("  Video Quality: %s\n", m.config.VideoQuality))
-	s.WriteString(fmt.Sprintf("  Output Folder: %s\n", m.config.OutputFolder))
+	s.WriteString(fmt.Sprintf("  Kind:          %s\n", m.backend.Config().Kind))
+	s.WriteString(fmt.Sprintf("  Format:        %s\n", m.backend.Config().Format))
+	s.WriteString(fmt.Sprintf("  Audio Quality: %s\n", m.backend.Config().AudioQuality))
+	s.WriteString(fmt.Sprintf("  Video Quality: %s\n", m.backend.Config().VideoQuality))
+	s.WriteString(fmt.Sprintf("  Output Folder: %s\n", m.backend.Config().OutputFolder))
 	if m.runtime != "" {
 		s.WriteString(fmt.Sprintf("  JS Runtime:    %s\n", m.runtime))
 	} else {
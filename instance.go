@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// A running TUI listens on a per-user socket (a named pipe on Windows) so
+// later `mldy URL...` invocations hand their URLs to it instead of opening
+// a second TUI on the same queue. A client sends one URL per line and an
+// empty line; the instance answers "ok" once it has taken them.
+
+// handoffTimeout is how long a client may take to send its URLs.
+const handoffTimeout = 5 * time.Second
+
+// handOff sends urls to a running instance and reports whether there was
+// one to take them.
+func handOff(urls []string) (bool, error) {
+	conn, err := dialInstance()
+	if err != nil {
+		return false, nil
+	}
+	defer conn.Close()
+
+	var b strings.Builder
+	for _, url := range urls {
+		b.WriteString(url + "\n")
+	}
+	b.WriteString("\n")
+	if _, err := io.WriteString(conn, b.String()); err != nil {
+		return true, err
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return true, err
+	}
+	if strings.TrimSpace(reply) != "ok" {
+		return true, fmt.Errorf("unexpected reply %q", strings.TrimSpace(reply))
+	}
+	return true, nil
+}
+
+// instanceServer takes URLs handed over by later invocations.
+type instanceServer struct {
+	accept func() (io.ReadWriteCloser, error)
+	close  func()
+	urls   chan []string
+}
+
+func newInstanceServer(accept func() (io.ReadWriteCloser, error), close func()) *instanceServer {
+	s := &instanceServer{accept: accept, close: close, urls: make(chan []string, 16)}
+	go s.serve()
+	return s
+}
+
+// URLs delivers each handed-over batch of URLs.
+func (s *instanceServer) URLs() <-chan []string {
+	return s.urls
+}
+
+func (s *instanceServer) Close() {
+	s.close()
+}
+
+func (s *instanceServer) serve() {
+	for {
+		conn, err := s.accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *instanceServer) handle(conn io.ReadWriteCloser) {
+	defer conn.Close()
+	// A client that connects and then stays silent mustn't keep this
+	// goroutine around forever.
+	if c, ok := conn.(interface{ SetDeadline(time.Time) error }); ok {
+		c.SetDeadline(time.Now().Add(handoffTimeout))
+	}
+	var urls []string
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			break
+		}
+		urls = append(urls, line)
+	}
+	if len(urls) > 0 {
+		s.urls <- urls
+	}
+	io.WriteString(conn, "ok\n")
+}
+
+// HandoffMsg carries URLs another invocation handed to this instance.
+type HandoffMsg struct {
+	URLs []string
+}
+
+func listenHandoff(ch <-chan []string) tea.Cmd {
+	return func() tea.Msg { return HandoffMsg{URLs: <-ch} }
+}
@@ -0,0 +1,74 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// instanceSocketPath returns $XDG_RUNTIME_DIR/mldy.sock, or run/mldy.sock
+// in the config directory where there's no runtime directory (macOS).
+func instanceSocketPath() (string, error) {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "mldy.sock"), nil
+	}
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "run", "mldy.sock"), nil
+}
+
+func dialInstance() (io.ReadWriteCloser, error) {
+	path, err := instanceSocketPath()
+	if err != nil {
+		return nil, err
+	}
+	return net.DialTimeout("unix", path, time.Second)
+}
+
+// listenInstance claims the socket. One nobody answers on is left over from
+// an instance that crashed, and is replaced.
+func listenInstance() (*instanceServer, error) {
+	path, err := instanceSocketPath()
+	if err != nil {
+		return nil, err
+	}
+	// Only this user may hand over URLs. The runtime directory is private
+	// already; the fallback one is made so before the socket appears in it.
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	if os.Getenv("XDG_RUNTIME_DIR") == "" {
+		if err := os.Chmod(dir, 0700); err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen("unix", path)
+	if errors.Is(err, syscall.EADDRINUSE) {
+		if conn, dialErr := net.DialTimeout("unix", path, time.Second); dialErr == nil {
+			conn.Close()
+			return nil, err
+		}
+		os.Remove(path)
+		l, err = net.Listen("unix", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return newInstanceServer(
+		func() (io.ReadWriteCloser, error) { return l.Accept() },
+		func() { l.Close() },
+	), nil
+}
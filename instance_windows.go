@@ -0,0 +1,56 @@
+//go:build windows
+
+package main
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// instancePipeName is a named pipe per user, e.g. \\.\pipe\mldy-alice.
+func instancePipeName() string {
+	return `\\.\pipe\mldy-` + os.Getenv("USERNAME")
+}
+
+func dialInstance() (io.ReadWriteCloser, error) {
+	return os.OpenFile(instancePipeName(), os.O_RDWR, 0)
+}
+
+// listenInstance creates the first instance of the pipe, which fails if
+// another mldy already has it. Each accepted client gets a fresh instance.
+func listenInstance() (*instanceServer, error) {
+	name, err := windows.UTF16PtrFromString(instancePipeName())
+	if err != nil {
+		return nil, err
+	}
+	create := func(flags uint32) (windows.Handle, error) {
+		return windows.CreateNamedPipe(name, windows.PIPE_ACCESS_DUPLEX|flags,
+			windows.PIPE_TYPE_BYTE|windows.PIPE_READMODE_BYTE|windows.PIPE_WAIT|windows.PIPE_REJECT_REMOTE_CLIENTS,
+			windows.PIPE_UNLIMITED_INSTANCES, 4096, 4096, 0, nil)
+	}
+	pipe, err := create(windows.FILE_FLAG_FIRST_PIPE_INSTANCE)
+	if err != nil {
+		return nil, err
+	}
+
+	accept := func() (io.ReadWriteCloser, error) {
+		if pipe == windows.InvalidHandle {
+			if pipe, err = create(0); err != nil {
+				return nil, err
+			}
+		}
+		if err := windows.ConnectNamedPipe(pipe, nil); err != nil && err != windows.ERROR_PIPE_CONNECTED {
+			windows.CloseHandle(pipe)
+			pipe = windows.InvalidHandle
+			return nil, err
+		}
+		conn := os.NewFile(uintptr(pipe), instancePipeName())
+		pipe = windows.InvalidHandle
+		return conn, nil
+	}
+	// The pipe goes away with the process; a blocked ConnectNamedPipe can't
+	// be interrupted from here anyway.
+	return newInstanceServer(accept, func() {}), nil
+}
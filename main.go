@@ -2,6 +2,7 @@ package main
 
 import (
 	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"os/exec"
@@ -12,11 +13,86 @@ import (
 )
 
 func main() {
+	// ── Subcommands ──────────────────────────────────────────────────────────
+	if len(os.Args) > 1 && os.Args[1] == "get" {
+		os.Exit(runGet(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		os.Exit(runServe(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		os.Exit(runDoctor())
+	}
+
+	// ── URLs to enqueue on startup ───────────────────────────────────────────
+	var files fileList
+	var connect, token string
+	flags := flag.NewFlagSet("mldy", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: mldy [-a FILE] [-connect HOST:PORT [-token TOKEN]] [URL...]")
+		fmt.Fprintln(os.Stderr, "       mldy get [flags] [URL...]")
+		fmt.Fprintln(os.Stderr, "       mldy serve [-addr HOST:PORT] [-token TOKEN]")
+		fmt.Fprintln(os.Stderr, "       mldy doctor")
+		fmt.Fprintln(os.Stderr, "\nURLs given here, in -a files or on piped stdin are added to the queue.")
+		flags.PrintDefaults()
+	}
+	flags.Var(&files, "a", "read URLs from a file (\"-\" for stdin); may be repeated")
+	flags.StringVar(&connect, "connect", "", "attach to a mldy serve daemon at HOST:PORT instead of downloading here")
+	flags.StringVar(&token, "token", os.Getenv("MLDY_TOKEN"), "bearer token for -connect (default $MLDY_TOKEN)")
+	flags.Parse(os.Args[1:])
+
+	startupURLs, err := collectURLs(flags.Args(), files)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	// ── Daemon ───────────────────────────────────────────────────────────────
+	// The daemon runs yt-dlp and ffmpeg, so none of the checks below apply.
+	if connect != "" {
+		backend, err := connectRemote(connect, token)
+		if err != nil {
+			fmt.Printf("Could not connect to %s: %v\n", connect, err)
+			os.Exit(1)
+		}
+		runTUI(backend, "", startupURLs, nil)
+		return
+	}
+
+	// ── Running instance ─────────────────────────────────────────────────────
+	// Another TUI already works on this queue; give it the URLs instead.
+	if handed, err := handOff(startupURLs); handed {
+		switch {
+		case err != nil:
+			fmt.Println("Could not hand URLs to the running mldy:", err)
+			os.Exit(1)
+		case len(startupURLs) > 0:
+			fmt.Printf("Added %d URL(s) to the running mldy.\n", len(startupURLs))
+		default:
+			fmt.Println("mldy is already running; `mldy URL...` adds URLs to it.")
+		}
+		os.Exit(0)
+	}
+
+	// ── Queue lock ───────────────────────────────────────────────────────────
+	// A daemon may own the queue instead; point at it rather than running the
+	// same entries twice.
+	if holder, err := lockQueue(""); errors.Is(err, errQueueLocked) {
+		if holder != "" {
+			fmt.Printf("mldy serve is running at %s and works on the queue. Attach to it with:\n", holder)
+			fmt.Printf("  mldy -connect %s [URL...]\n", holder)
+		} else {
+			fmt.Println("Another mldy is already working on the queue.")
+		}
+		os.Exit(1)
+	}
+
 	// ── yt-dlp ───────────────────────────────────────────────────────────────
 	if _, err := exec.LookPath("yt-dlp"); err != nil {
 		fmt.Println("yt-dlp not found.")
 		if askYesNo("Install yt-dlp now?") {
-			if err := installYtDlp(); err != nil {
+			config, _ := loadConfig()
+			if err := installYtDlp(config.Network); err != nil {
 				fmt.Println("Auto-install failed:", err)
 				printYtDlpGuide()
 				os.Exit(1)
@@ -84,11 +160,24 @@ func main() {
 	}
 
 	// ── TUI ───────────────────────────────────────────────────────────────────
+	// Without the socket this TUI still works; it just can't take URLs from
+	// later invocations.
+	var handoffs <-chan []string
+	if server, err := listenInstance(); err == nil {
+		defer server.Close()
+		handoffs = server.URLs()
+	}
+	runTUI(openLocalBackend(runtime), runtime, startupURLs, handoffs)
+}
+
+// runTUI runs the TUI until it quits. handoffs, when not nil, delivers URLs
+// handed over by later invocations.
+func runTUI(backend Backend, runtime string, startupURLs []string, handoffs <-chan []string) {
 	zone.NewGlobal()
 	defer zone.Close()
 
 	p := tea.NewProgram(
-		initialModel(runtime),
+		initialModel(backend, runtime, startupURLs, handoffs),
 		// tea.WithAltScreen(),
 		// tea.WithMouseCellMotion(), // enables click events
 	)
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"strings"
 
 	"charm.land/bubbles/v2/progress"
@@ -17,19 +16,56 @@ const (
 	ScreenInput Screen = iota
 	ScreenDownload
 	ScreenHistory
+	ScreenSettings
+
+	screenCount
 )
 
 type Model struct {
-	screen     Screen
-	config     Config
-	queue      *Queue
-	downloader *Downloader
-	runtime    string
-
-	isRunning      bool
-	resolvingCount int
-
-	progressCh chan tea.Msg
+	screen  Screen
+	backend Backend
+	runtime string
+
+	// queueCursor indexes Queue.GetQueued on the Input screen; override
+	// is non-nil while its settings editor is open.
+	queueCursor int
+	override    *overrideEditor
+
+	// profile names the config profile applied to newly added URLs; empty
+	// means the global settings. redownload makes them bypass the archive.
+	profile    string
+	redownload bool
+
+	// downloadCursor indexes Queue.GetInProgress on the Downloads screen.
+	downloadCursor int
+
+	historySearch textinput.Model
+	historyFilter HistoryFilter
+	historyPage   int
+
+	settingsForm   configForm
+	settingsErr    error
+	settingsStatus string
+
+	// cookieChecks are refreshed every time the Settings screen opens.
+	cookieChecks []cookieReport
+
+	// startupURLs come from the command line and are resolved by Init;
+	// handoffs, from later `mldy URL...` invocations.
+	startupURLs []string
+	handoffs    <-chan []string
+
+	// clipboardText is the clipboard content the watcher saw last, once
+	// clipboardPrimed; clipboardSeen holds the URLs it queued.
+	clipboardText   string
+	clipboardPrimed bool
+	clipboardSeen   map[string]bool
+	clipboardErr    error
+
+	// toast is a short notice on the Input screen; toastID tells its
+	// expiry apart from that of an older one.
+	toast   string
+	toastID int
 
 	urlInput        textinput.Model
 	currentProgress progress.Model
@@ -39,63 +75,103 @@ type Model struct {
 	height int
 }
 
-func initialModel(runtime string) Model {
-	config, _ := loadConfig()
-
+func initialModel(backend Backend, runtime string, startupURLs []string, handoffs <-chan []string) Model {
 	ti := textinput.New()
 	ti.Placeholder = "Enter YouTube URL or playlist..."
 	ti.Focus()
 	ti.CharLimit = 500
 	ti.SetWidth(80)
 
+	search := textinput.New()
+	search.Placeholder = "title, URL, path or error..."
+	search.CharLimit = 200
+	search.SetWidth(40)
+
 	prog := progress.New()
 	prog.SetWidth(80)
 
 	return Model{
 		screen:          ScreenInput,
-		config:          config,
-		queue:           NewQueue(),
-		downloader:      NewDownloader(config, runtime),
+		backend:         backend,
 		runtime:         runtime,
-		progressCh:      make(chan tea.Msg, 64),
 		urlInput:        ti,
+		historySearch:   search,
+		settingsForm:    newSettingsForm(backend.Config()),
+		startupURLs:     startupURLs,
+		handoffs:        handoffs,
+		clipboardSeen:   make(map[string]bool),
 		currentProgress: prog,
 		overallProgress: prog,
 	}
 }
 
 func (m Model) Init() tea.Cmd {
-	return textinput.Blink
+	cmds := []tea.Cmd{
+		textinput.Blink,
+		m.backend.Init(),
+		m.backend.Add(m.startupURLs, EntryConfig{}),
+		pollClipboard(m.backend.Config().ClipboardWatch),
+	}
+	if m.handoffs != nil {
+		cmds = append(cmds, listenHandoff(m.handoffs))
+	}
+	return tea.Batch(cmds...)
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 
+	// Queue updates, from the engine or a daemon, go to the backend.
+	if cmd, ok := m.backend.Update(msg); ok {
+		return m, cmd
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// While the history search box has focus it gets every key.
+		if m.screen == ScreenHistory && m.historySearch.Focused() {
+			return m.updateHistorySearch(msg)
+		}
+		// So does an open override editor, apart from quitting.
+		if m.screen == ScreenInput && m.override != nil && msg.String() != "ctrl+c" {
+			return m.updateOverride(msg)
+		}
+		// The settings form takes every key but screen switching and quit.
+		if m.screen == ScreenSettings {
+			switch msg.String() {
+			case "tab", "shift+tab", "ctrl+c":
+			default:
+				return m.updateSettings(msg)
+			}
+		}
+
 		switch msg.String() {
 		case "ctrl+c", "q":
+			m.backend.Close()
 			return m, tea.Quit
 		case "tab":
-			m.screen = (m.screen + 1) % 3
-			return m, nil
+			return m.switchScreen((m.screen + 1) % screenCount)
 		case "shift+tab":
-			if m.screen == 0 {
-				m.screen = 2
-			} else {
-				m.screen--
-			}
-			return m, nil
+			return m.switchScreen((m.screen + screenCount - 1) % screenCount)
 		case "enter":
 			if m.screen == ScreenInput {
-				url := strings.TrimSpace(m.urlInput.Value())
-				if url == "" {
+				urls, _ := parseURLList(strings.NewReader(m.urlInput.Value()))
+				if len(urls) == 0 {
 					return m, nil
 				}
 				m.urlInput.SetValue("")
-				m.resolvingCount++
-				return m, m.downloader.ResolvePlaylist(url, EntryConfig{})
+				return m, m.backend.Add(urls, m.newEntryConfig())
+			}
+		case "ctrl+p":
+			if m.screen == ScreenInput {
+				m.cycleProfile()
+				return m, nil
+			}
+		case "ctrl+f":
+			if m.screen == ScreenInput {
+				m.redownload = !m.redownload
+				return m, nil
 			}
 		case "ctrl+d":
 			return m.tryStartDownloads()
@@ -103,6 +179,93 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.screen == ScreenInput && m.urlInput.Value() == "" {
 				return m.tryRemoveLast()
 			}
+		case "up", "k":
+			if m.screen == ScreenDownload {
+				m.downloadCursor = max(0, m.downloadCursor-1)
+				return m, nil
+			}
+			if m.screen == ScreenInput && msg.String() == "up" {
+				m.queueCursor = max(0, m.queueCursor-1)
+				return m, nil
+			}
+		case "down", "j":
+			if m.screen == ScreenDownload {
+				m.downloadCursor = min(m.downloadCursor+1, max(0, len(m.backend.Queue().GetInProgress())-1))
+				return m, nil
+			}
+			if m.screen == ScreenInput && msg.String() == "down" {
+				m.queueCursor = min(m.queueCursor+1, max(0, len(m.backend.Queue().GetQueued())-1))
+				return m, nil
+			}
+		case "ctrl+e":
+			if m.screen == ScreenInput {
+				if entry, ok := m.selectedQueued(); ok {
+					return m, m.editQueued(entry)
+				}
+				return m, nil
+			}
+		case "ctrl+g":
+			if m.screen == ScreenInput {
+				if entry, ok := m.selectedQueued(); ok {
+					return m, m.editPlaylist(entry)
+				}
+				return m, nil
+			}
+		case "+", "=":
+			if m.screen == ScreenDownload {
+				return m.stepRateLimit(1)
+			}
+		case "-":
+			if m.screen == ScreenDownload {
+				return m.stepRateLimit(-1)
+			}
+		case "p":
+			if m.screen == ScreenDownload {
+				if entry, ok := m.selectedInProgress(); ok {
+					return m, m.backend.Pause(entry.ID)
+				}
+				return m, nil
+			}
+		case "r":
+			if m.screen == ScreenDownload {
+				if entry, ok := m.selectedInProgress(); ok {
+					return m, m.backend.Resume(entry.ID)
+				}
+				return m, nil
+			}
+		case "x":
+			if m.screen == ScreenDownload {
+				if entry, ok := m.selectedInProgress(); ok {
+					return m, m.backend.Cancel(entry.ID)
+				}
+				return m, nil
+			}
+		case "/":
+			if m.screen == ScreenHistory {
+				return m, m.historySearch.Focus()
+			}
+		case "f":
+			if m.screen == ScreenHistory {
+				m.historyFilter = (m.historyFilter + 1) % historyFilterCount
+				m.historyPage = 0
+				return m, nil
+			}
+		case "left", "h":
+			if m.screen == ScreenHistory {
+				m.historyPage = max(0, m.historyPage-1)
+				return m, nil
+			}
+		case "right", "l":
+			if m.screen == ScreenHistory {
+				m.historyPage = min(m.historyPage+1, m.historyPageCount()-1)
+				return m, nil
+			}
+		case "esc":
+			if m.screen == ScreenHistory {
+				m.historySearch.SetValue("")
+				m.historyPage = 0
+				return m, nil
+			}
 		}
 
 	// ── Mouse clicks ─────────────────────────────────────────────────────────
@@ -114,14 +277,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Tab clicks
 		switch {
 		case zone.Get(zoneTabInput).InBounds(msg):
-			m.screen = ScreenInput
-			return m, nil
+			return m.switchScreen(ScreenInput)
 		case zone.Get(zoneTabDownload).InBounds(msg):
-			m.screen = ScreenDownload
-			return m, nil
+			return m.switchScreen(ScreenDownload)
 		case zone.Get(zoneTabHistory).InBounds(msg):
-			m.screen = ScreenHistory
-			return m, nil
+			return m.switchScreen(ScreenHistory)
+		case zone.Get(zoneTabSettings).InBounds(msg):
+			return m.switchScreen(ScreenSettings)
 		}
 
 		// Action buttons
@@ -131,19 +293,118 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if zone.Get(zoneRemoveBtn).InBounds(msg) {
 			return m.tryRemoveLast()
 		}
+		if zone.Get(zoneProfileBtn).InBounds(msg) {
+			m.cycleProfile()
+			return m, nil
+		}
+		if zone.Get(zoneRedownloadBtn).InBounds(msg) {
+			m.redownload = !m.redownload
+			return m, nil
+		}
+
+		// Override editor rows and buttons
+		if m.screen == ScreenInput && m.override != nil {
+			if i, ok := m.override.form.FieldAt(overrideFormName, msg); ok {
+				return m, m.override.form.Focus(i)
+			}
+			switch {
+			case zone.Get(zoneOverrideSave).InBounds(msg):
+				return m.saveOverride()
+			case zone.Get(zoneOverrideCancel).InBounds(msg):
+				return m, m.closeOverrideEditor()
+			}
+			return m, nil
+		}
+
+		// Per-entry ✕ and ⚙ buttons, and ⚙ on playlist headers
+		for i, entry := range m.backend.Queue().GetQueued() {
+			switch {
+			case zone.Get(zoneRemoveEntry(entry.ID)).InBounds(msg):
+				return m, m.backend.Remove(entry.ID)
+			case zone.Get(zoneEditEntry(entry.ID)).InBounds(msg):
+				m.queueCursor = i
+				return m, m.editQueued(entry)
+			case zone.Get(zoneEditPlaylist(entry.ID)).InBounds(msg):
+				m.queueCursor = i
+				return m, m.editPlaylist(entry)
+			}
+		}
+
+		// ↻ on skipped entries
+		if m.screen == ScreenInput {
+			for _, entry := range m.backend.Queue().GetSkipped() {
+				if zone.Get(zoneRedownloadEntry(entry.ID)).InBounds(msg) {
+					return m, m.backend.Redownload(entry.ID)
+				}
+			}
+		}
 
-		// Per-entry ✕ buttons
-		for _, entry := range m.queue.GetQueued() {
-			if zone.Get(zoneRemoveEntry(entry.ID)).InBounds(msg) {
-				m.queue.Remove(entry.ID)
+		// History search, filter and paging
+		if m.screen == ScreenHistory {
+			switch {
+			case zone.Get(zoneHistorySearch).InBounds(msg):
+				return m, m.historySearch.Focus()
+			case zone.Get(zoneHistoryFilter).InBounds(msg):
+				m.historyFilter = (m.historyFilter + 1) % historyFilterCount
+				m.historyPage = 0
+				return m, nil
+			case zone.Get(zoneHistoryPrev).InBounds(msg):
+				m.historyPage = max(0, m.historyPage-1)
+				return m, nil
+			case zone.Get(zoneHistoryNext).InBounds(msg):
+				m.historyPage = min(m.historyPage+1, m.historyPageCount()-1)
 				return m, nil
 			}
 		}
 
+		// Settings form rows and buttons
+		if m.screen == ScreenSettings {
+			if i, ok := m.settingsForm.FieldAt(settingsFormName, msg); ok {
+				return m, m.settingsForm.Focus(i)
+			}
+			switch {
+			case zone.Get(zoneSettingsSave).InBounds(msg):
+				return m.saveSettings()
+			case zone.Get(zoneSettingsReset).InBounds(msg):
+				m.settingsForm.SetConfig(m.backend.Config())
+				m.settingsErr = nil
+				m.settingsStatus = "Changes discarded"
+				return m, nil
+			}
+		}
+
+		// Per-entry pause/resume/cancel buttons on the Downloads screen
+		for i, entry := range m.backend.Queue().GetInProgress() {
+			switch {
+			case zone.Get(zonePauseEntry(entry.ID)).InBounds(msg):
+				m.downloadCursor = i
+				return m, m.backend.Pause(entry.ID)
+			case zone.Get(zoneResumeEntry(entry.ID)).InBounds(msg):
+				m.downloadCursor = i
+				return m, m.backend.Resume(entry.ID)
+			case zone.Get(zoneCancelEntry(entry.ID)).InBounds(msg):
+				m.downloadCursor = i
+				return m, m.backend.Cancel(entry.ID)
+			}
+		}
+
 	case tea.MouseWheelMsg:
 		// Optional: handle scroll if needed
 
 	// ── Domain messages ───────────────────────────────────────────────────────
+	case HandoffMsg:
+		// Like URLs given on the command line, these skip the active profile.
+		return m, tea.Batch(m.backend.Add(msg.URLs, EntryConfig{}), listenHandoff(m.handoffs))
+
+	case ClipboardMsg:
+		return m, m.updateClipboard(msg)
+
+	case ToastExpiredMsg:
+		if msg.ID == m.toastID {
+			m.toast = ""
+		}
+		return m, nil
+
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
@@ -157,59 +418,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.overallProgress.SetWidth(targetWidth)
 
 		return m, nil
-
-	case PlaylistResolvedMsg:
-		m.resolvingCount--
-		if msg.Error != nil {
-			m.queue.Add(msg.OriginalURL, msg.Config)
-			id := m.queue.Entries[len(m.queue.Entries)-1].ID
-			m.queue.Update(id, func(e *DownloadEntry) {
-				e.Status = StatusFailed
-				e.Error = fmt.Sprintf("playlist resolve error: %v", msg.Error)
-			})
-			return m, nil
-		}
-		if msg.PlaylistTitle != "" {
-			m.queue.AddPlaylistItems(msg.Items, msg.PlaylistTitle, msg.Config)
-		} else if len(msg.Items) > 0 {
-			item := msg.Items[0]
-			m.queue.Add(item.URL, msg.Config)
-			if item.Title != "" {
-				id := m.queue.Entries[len(m.queue.Entries)-1].ID
-				m.queue.Update(id, func(e *DownloadEntry) { e.Title = item.Title })
-			}
-		}
-		return m, nil
-
-	case ProgressMsg:
-		m.queue.Update(msg.ID, func(e *DownloadEntry) {
-			e.Progress = msg.Progress
-			if msg.Title != "" {
-				e.Title = msg.Title
-			}
-		})
-		return m, listenProgress(m.progressCh)
-
-	case DownloadCompleteMsg:
-		m.queue.Update(msg.ID, func(e *DownloadEntry) {
-			if msg.Error != nil {
-				e.Status = StatusFailed
-				e.Error = msg.Error.Error()
-			} else {
-				e.Status = StatusCompleted
-				e.OutputPath = msg.OutputPath
-			}
-		})
-		if m.isRunning {
-			return m, m.startNextDownload()
-		}
-		return m, nil
 	}
 
-	if m.screen == ScreenInput {
-		// On Windows, pastes often contain \r or \n which can crash the renderer
-		// if textinput isn't expecting them (e.g. single-line mode).
+	if m.screen == ScreenInput && m.override == nil {
+		// A paste holding several URLs (one per line, or space separated)
+		// enqueues all of them instead of gluing them into one bogus URL.
+		// Single-URL pastes go into the input as before; on Windows they often
+		// carry \r or \n, which can crash the renderer in single-line mode.
 		if p, ok := msg.(tea.PasteMsg); ok {
+			if urls, _ := parseURLList(strings.NewReader(p.Content)); len(urls) > 1 {
+				return m, m.backend.Add(urls, m.newEntryConfig())
+			}
 			clean := strings.ReplaceAll(p.Content, "\r", "")
 			clean = strings.ReplaceAll(clean, "\n", "")
 			msg = tea.PasteMsg{Content: clean}
@@ -228,6 +447,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 	}
 
+	// Keeps the search and settings cursors blinking; keys were handled above.
+	if m.screen == ScreenHistory && m.historySearch.Focused() {
+		m.historySearch, cmd = m.historySearch.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	if m.screen == ScreenSettings {
+		cmds = append(cmds, m.settingsForm.Update(msg))
+	}
+	if m.screen == ScreenInput && m.override != nil {
+		cmds = append(cmds, m.override.form.Update(msg))
+	}
+
 	return m, tea.Batch(cmds...)
 }
 
@@ -247,6 +478,8 @@ func (m Model) View() tea.View {
 		s.WriteString(m.renderDownloadScreen())
 	case ScreenHistory:
 		s.WriteString(m.renderHistoryScreen())
+	case ScreenSettings:
+		s.WriteString(m.renderSettingsScreen())
 	}
 
 	s.WriteString("\n\n")
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
 )
@@ -11,45 +12,181 @@ const (
 	zoneTabInput    = "tab-input"
 	zoneTabDownload = "tab-download"
 	zoneTabHistory  = "tab-history"
+	zoneTabSettings = "tab-settings"
 	zoneStartBtn    = "btn-start"
 	zoneRemoveBtn   = "btn-remove-last"
-	// Per-entry remove buttons use "btn-remove-<entry.ID>", built dynamically.
+	zoneProfileBtn  = "btn-profile"
+
+	zoneRedownloadBtn = "btn-redownload"
+
+	zoneHistorySearch = "history-search"
+	zoneHistoryFilter = "btn-history-filter"
+	zoneHistoryPrev   = "btn-history-prev"
+	zoneHistoryNext   = "btn-history-next"
+
+	zoneSettingsSave  = "btn-settings-save"
+	zoneSettingsReset = "btn-settings-reset"
+
+	zoneOverrideSave   = "btn-override-save"
+	zoneOverrideCancel = "btn-override-cancel"
+	// Per-entry buttons use "btn-<action>-<entry.ID>", built dynamically.
 )
 
 func zoneRemoveEntry(id int) string {
 	return fmt.Sprintf("btn-remove-%d", id)
 }
 
-func listenProgress(ch chan tea.Msg) tea.Cmd {
+func zoneEditEntry(id int) string {
+	return fmt.Sprintf("btn-edit-%d", id)
+}
+
+// zoneEditPlaylist is keyed by the ID of the playlist's first queued entry.
+func zoneEditPlaylist(firstID int) string {
+	return fmt.Sprintf("btn-edit-playlist-%d", firstID)
+}
+
+func zoneRedownloadEntry(id int) string {
+	return fmt.Sprintf("btn-redownload-%d", id)
+}
+
+func zonePauseEntry(id int) string {
+	return fmt.Sprintf("btn-pause-%d", id)
+}
+
+func zoneResumeEntry(id int) string {
+	return fmt.Sprintf("btn-resume-%d", id)
+}
+
+func zoneCancelEntry(id int) string {
+	return fmt.Sprintf("btn-cancel-%d", id)
+}
+
+func listenProgress(ch <-chan tea.Msg) tea.Cmd {
 	return func() tea.Msg { return <-ch }
 }
 
-func (m *Model) startNextDownload() tea.Cmd {
-	queued := m.queue.GetQueued()
-	if len(queued) == 0 {
-		m.isRunning = false
-		return nil
+// switchScreen changes tabs, moving keyboard focus to the Settings form when
+// it becomes visible.
+func (m *Model) switchScreen(screen Screen) (tea.Model, tea.Cmd) {
+	m.screen = screen
+	if screen == ScreenSettings {
+		// A daemon keeps its cookie sources to itself.
+		if m.backend.Location() == "" {
+			m.cookieChecks = checkCookies(m.backend.Config(), time.Now())
+		}
+		return m, m.settingsForm.Focus(m.settingsForm.focus)
 	}
-	entry := queued[0]
-	m.queue.Update(entry.ID, func(e *DownloadEntry) { e.Status = StatusDownloading })
-	return tea.Batch(
-		m.downloader.StartDownload(m.queue.GetByID(entry.ID), m.progressCh),
-		listenProgress(m.progressCh),
-	)
+	m.settingsForm.Blur()
+	m.historySearch.Blur()
+	return m, nil
 }
 
 func (m *Model) tryStartDownloads() (tea.Model, tea.Cmd) {
-	if !m.isRunning && m.resolvingCount == 0 && len(m.queue.GetQueued()) > 0 {
-		m.isRunning = true
-		return m, m.startNextDownload()
+	if m.backend.Resolving() > 0 {
+		return m, nil
 	}
-	return m, nil
+	return m, m.backend.Start()
 }
 
 func (m *Model) tryRemoveLast() (tea.Model, tea.Cmd) {
-	queued := m.queue.GetQueued()
+	queued := m.backend.Queue().GetQueued()
 	if len(queued) > 0 {
-		m.queue.Remove(queued[len(queued)-1].ID)
+		return m, m.backend.Remove(queued[len(queued)-1].ID)
 	}
 	return m, nil
 }
+
+// cycleProfile switches to the next config profile, wrapping around
+// through "no profile".
+func (m *Model) cycleProfile() {
+	names := append([]string{""}, m.backend.Config().ProfileNames()...)
+	next := 0
+	for i, name := range names {
+		if name == m.profile {
+			next = (i + 1) % len(names)
+		}
+	}
+	m.profile = names[next]
+}
+
+// activeProfile is the override given to URLs added on the Input screen.
+func (m *Model) activeProfile() EntryConfig {
+	profile, _ := m.backend.Config().Profile(m.profile)
+	return profile
+}
+
+// newEntryConfig is the active profile plus the re-download toggle.
+func (m *Model) newEntryConfig() EntryConfig {
+	cfg := m.activeProfile()
+	if m.redownload {
+		skip := false
+		cfg.SkipDownloaded = &skip
+	}
+	return cfg
+}
+
+// selectedQueued returns the entry under the Input screen's queue cursor.
+func (m *Model) selectedQueued() (DownloadEntry, bool) {
+	entries := m.backend.Queue().GetQueued()
+	if len(entries) == 0 {
+		return DownloadEntry{}, false
+	}
+	m.queueCursor = max(0, min(m.queueCursor, len(entries)-1))
+	return entries[m.queueCursor], true
+}
+
+// selectedInProgress returns the entry under the Downloads screen cursor.
+func (m *Model) selectedInProgress() (DownloadEntry, bool) {
+	entries := m.backend.Queue().GetInProgress()
+	if len(entries) == 0 {
+		return DownloadEntry{}, false
+	}
+	m.downloadCursor = max(0, min(m.downloadCursor, len(entries)-1))
+	return entries[m.downloadCursor], true
+}
+
+// updateHistorySearch feeds keys to the focused history search box.
+func (m *Model) updateHistorySearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.backend.Close()
+		return m, tea.Quit
+	case "enter", "esc", "tab", "shift+tab":
+		m.historySearch.Blur()
+		return m, nil
+	}
+
+	before := m.historySearch.Value()
+	var cmd tea.Cmd
+	m.historySearch, cmd = m.historySearch.Update(msg)
+	if m.historySearch.Value() != before {
+		m.historyPage = 0
+	}
+	return m, cmd
+}
+
+// historyPageSize is how many records fit on the History screen at once.
+func (m *Model) historyPageSize() int {
+	// Tabs, title, search bar, paging line and footer take ~12 rows; a
+	// record takes about three.
+	return max(1, (m.height-12)/3)
+}
+
+func (m *Model) historyPageCount() int {
+	n := len(m.backend.History().Search(m.historySearch.Value(), m.historyFilter))
+	return max(1, (n+m.historyPageSize()-1)/m.historyPageSize())
+}
+
+// formatBytes renders a byte count like "12.3 MiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
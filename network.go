@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NetworkConfig routes the connections of yt-dlp and of mldy's own
+// downloads during setup. Zero values leave the system defaults alone.
+type NetworkConfig struct {
+	// Proxy is an HTTP or SOCKS proxy URL like "http://proxy:3128" or
+	// "socks5://127.0.0.1:1080". In a site override, "direct" connects
+	// without the global proxy.
+	Proxy string `yaml:"proxy,omitempty"`
+
+	// SourceAddress is the local IP address to connect from.
+	SourceAddress string `yaml:"source_address,omitempty"`
+
+	// IPVersion forces "4" or "6"; empty uses whichever works.
+	IPVersion string `yaml:"ip_version,omitempty"`
+
+	// SocketTimeout in seconds; 0 keeps the default.
+	SocketTimeout int `yaml:"socket_timeout,omitempty"`
+
+	// Sites override the settings above for sites like "youtube.com",
+	// subdomains included.
+	Sites map[string]NetworkConfig `yaml:"sites,omitempty"`
+}
+
+// directProxy is the site override value that disables the global proxy.
+const directProxy = "direct"
+
+var proxySchemes = []string{"http", "https", "socks4", "socks4a", "socks5", "socks5h"}
+
+// siteFor returns the value of m for rawURL's site: the longest key the
+// host equals or is a subdomain of, else the "*" entry.
+func siteFor[T any](m map[string]T, rawURL string) (T, bool) {
+	var best string
+	var value T
+	found := false
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return value, false
+	}
+	host := strings.ToLower(u.Hostname())
+	for site, v := range m {
+		site = strings.ToLower(site)
+		switch {
+		case site == "*" && best == "":
+			value, found = v, true
+		case (host == site || strings.HasSuffix(host, "."+site)) && len(site) > len(best):
+			best, value, found = site, v, true
+		}
+	}
+	return value, found
+}
+
+// For returns the settings that apply to rawURL, with its site's
+// override laid over the global ones.
+func (n NetworkConfig) For(rawURL string) NetworkConfig {
+	merged := n
+	merged.Sites = nil
+	site, ok := siteFor(n.Sites, rawURL)
+	if !ok {
+		return merged
+	}
+	if site.Proxy != "" {
+		merged.Proxy = site.Proxy
+	}
+	if site.SourceAddress != "" {
+		merged.SourceAddress = site.SourceAddress
+	}
+	if site.IPVersion != "" {
+		merged.IPVersion = site.IPVersion
+	}
+	if site.SocketTimeout > 0 {
+		merged.SocketTimeout = site.SocketTimeout
+	}
+	return merged
+}
+
+// args returns the yt-dlp flags for n; call it on the result of For.
+func (n NetworkConfig) args() []string {
+	var args []string
+	switch n.Proxy {
+	case "":
+	case directProxy:
+		// An empty proxy makes yt-dlp ignore the environment's proxy too.
+		args = append(args, "--proxy", "")
+	default:
+		args = append(args, "--proxy", n.Proxy)
+	}
+	if n.SourceAddress != "" {
+		args = append(args, "--source-address", n.SourceAddress)
+	}
+	switch n.IPVersion {
+	case "4":
+		args = append(args, "--force-ipv4")
+	case "6":
+		args = append(args, "--force-ipv6")
+	}
+	if n.SocketTimeout > 0 {
+		args = append(args, "--socket-timeout", strconv.Itoa(n.SocketTimeout))
+	}
+	return args
+}
+
+// httpClient returns a client that connects the way n says; call it on
+// the result of For. Without a proxy set it honors HTTPS_PROXY and friends.
+func (n NetworkConfig) httpClient() (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	switch n.Proxy {
+	case "":
+	case directProxy:
+		transport.Proxy = nil
+	default:
+		proxy, err := url.Parse(n.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy %q: %w", n.Proxy, err)
+		}
+		// yt-dlp speaks SOCKS4, but Go's HTTP client doesn't.
+		if proxy.Scheme == "socks4" || proxy.Scheme == "socks4a" {
+			return nil, fmt.Errorf("mldy can't download through the %s proxy %s itself; use an http or socks5 proxy, or install yt-dlp by hand", proxy.Scheme, proxy.Host)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if n.SocketTimeout > 0 {
+		dialer.Timeout = time.Duration(n.SocketTimeout) * time.Second
+	}
+	if n.SourceAddress != "" {
+		dialer.LocalAddr = &net.TCPAddr{IP: net.ParseIP(n.SourceAddress)}
+	}
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if n.IPVersion != "" {
+			network = "tcp" + n.IPVersion
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// validate reports the first setting yt-dlp would reject.
+func (n NetworkConfig) validate() error {
+	if n.Proxy != "" && n.Proxy != directProxy {
+		u, err := url.Parse(n.Proxy)
+		if err != nil || !slices.Contains(proxySchemes, u.Scheme) || u.Host == "" {
+			return fmt.Errorf("proxy must be a URL like http://host:port or socks5://host:port, not %q", n.Proxy)
+		}
+	}
+	if n.SourceAddress != "" && net.ParseIP(n.SourceAddress) == nil {
+		return fmt.Errorf("source address must be an IP address, not %q", n.SourceAddress)
+	}
+	if n.IPVersion != "" && n.IPVersion != "4" && n.IPVersion != "6" {
+		return fmt.Errorf("ip version must be 4 or 6, not %q", n.IPVersion)
+	}
+	if n.SocketTimeout < 0 {
+		return fmt.Errorf("socket timeout can't be negative, got %d", n.SocketTimeout)
+	}
+	return nil
+}
+
+// summary describes n in a few words for `mldy doctor`, e.g.
+// "proxy http://proxy:3128, IPv4, 1 site override"; empty when n is zero.
+// Passwords in the proxy URL are masked.
+func (n NetworkConfig) summary() string {
+	var parts []string
+	switch n.Proxy {
+	case "":
+	case directProxy:
+		parts = append(parts, "no proxy")
+	default:
+		proxy := n.Proxy
+		if u, err := url.Parse(n.Proxy); err == nil {
+			proxy = u.Redacted()
+		}
+		parts = append(parts, "proxy "+proxy)
+	}
+	if n.SourceAddress != "" {
+		parts = append(parts, "from "+n.SourceAddress)
+	}
+	if n.IPVersion != "" {
+		parts = append(parts, "IPv"+n.IPVersion)
+	}
+	if n.SocketTimeout > 0 {
+		parts = append(parts, fmt.Sprintf("timeout %ds", n.SocketTimeout))
+	}
+	switch len(n.Sites) {
+	case 0:
+	case 1:
+		parts = append(parts, "1 site override")
+	default:
+		parts = append(parts, fmt.Sprintf("%d site overrides", len(n.Sites)))
+	}
+	return strings.Join(parts, ", ")
+}
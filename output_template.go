@@ -0,0 +1,65 @@
+package main
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Default filename templates, relative to the output folder. Playlist items
+// go into a folder per playlist, numbered so they sort in playlist order and
+// repeated titles don't overwrite each other.
+const (
+	defaultOutputTemplate         = "%(title)s.%(ext)s"
+	defaultPlaylistOutputTemplate = "{playlist_title}/{playlist_index} - %(title)s.%(ext)s"
+)
+
+// outputTemplate returns the yt-dlp -o value for entry: the matching
+// template with mldy's {placeholders} filled in, joined to the output folder
+// unless it is absolute. yt-dlp's own %(fields)s are left for yt-dlp.
+func outputTemplate(cfg Config, entry DownloadEntry) string {
+	tmpl := cfg.OutputTemplate
+	if entry.Playlist != nil {
+		tmpl = cfg.PlaylistOutputTemplate
+	}
+
+	var title, index, count string
+	if p := entry.Playlist; p != nil {
+		title = sanitizeTemplateValue(p.PlaylistTitle)
+		// Pad to the width of the total so "02" sorts before "10".
+		width := max(2, len(strconv.Itoa(p.Total)))
+		index = strings.Repeat("0", max(0, width-len(strconv.Itoa(p.Index)))) + strconv.Itoa(p.Index)
+		count = strconv.Itoa(p.Total)
+	}
+	tmpl = strings.NewReplacer(
+		"{playlist_title}", title,
+		"{playlist_index}", index,
+		"{playlist_count}", count,
+	).Replace(tmpl)
+
+	if filepath.IsAbs(tmpl) {
+		return tmpl
+	}
+	return filepath.Join(cfg.OutputFolder, tmpl)
+}
+
+// sanitizeTemplateValue makes s safe as a single path component inside a
+// yt-dlp template: no separators or characters Windows rejects, and "%"
+// escaped so yt-dlp doesn't read it as a field.
+func sanitizeTemplateValue(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		if r < ' ' {
+			return -1
+		}
+		return r
+	}, s)
+	s = strings.Trim(s, " .")
+	if s == "" {
+		s = "Playlist"
+	}
+	return strings.ReplaceAll(s, "%", "%%")
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	zone "github.com/lrstanley/bubblezone/v2"
+)
+
+const overrideFormName = "override"
+
+// overrideEditor edits the EntryConfig of one queued entry, or of every
+// queued entry of a playlist at once.
+type overrideEditor struct {
+	ids   []int
+	title string
+	form  configForm
+	err   error
+}
+
+// openOverrideEditor starts editing the given entries, pre-filled from the
+// first one's override.
+func (m *Model) openOverrideEditor(title string, ids []int) tea.Cmd {
+	first := m.backend.Queue().GetByID(ids[0])
+	if first == nil {
+		return nil
+	}
+	form := newConfigForm(fieldKind, fieldFormat, fieldAudioQuality, fieldVideoQuality, fieldOutputFolder,
+		fieldOutputTemplate, fieldPlaylistOutputTemplate, fieldRateLimit, fieldSkipDownloaded,
+		fieldSubtitleLangs, fieldAutoSubtitles, fieldSubtitleFormat, fieldEmbedSubtitles,
+		fieldEmbedChapters, fieldSplitChapters, fieldSponsorBlock, fieldSponsorBlockCategories)
+	form.SetEntryConfig(first.Config, m.backend.Config())
+
+	m.override = &overrideEditor{ids: ids, title: title, form: form}
+	m.urlInput.Blur()
+	return m.override.form.Focus(0)
+}
+
+// editQueued opens the editor for a single queued entry.
+func (m *Model) editQueued(entry DownloadEntry) tea.Cmd {
+	return m.openOverrideEditor(entry.PlaylistLabel()+entry.DisplayTitle(), []int{entry.ID})
+}
+
+// editPlaylist opens the editor for every queued entry of entry's playlist.
+func (m *Model) editPlaylist(entry DownloadEntry) tea.Cmd {
+	if entry.Playlist == nil {
+		return m.editQueued(entry)
+	}
+	var ids []int
+	for _, e := range m.backend.Queue().GetQueued() {
+		if e.Playlist != nil && e.Playlist.PlaylistTitle == entry.Playlist.PlaylistTitle {
+			ids = append(ids, e.ID)
+		}
+	}
+	return m.openOverrideEditor(fmt.Sprintf("▶ %s (%d entries)", entry.Playlist.PlaylistTitle, len(ids)), ids)
+}
+
+func (m *Model) closeOverrideEditor() tea.Cmd {
+	m.override = nil
+	return m.urlInput.Focus()
+}
+
+// saveOverride validates the form and stores the override on every target.
+func (m *Model) saveOverride() (tea.Model, tea.Cmd) {
+	cfg, err := m.override.form.EntryConfig()
+	if err != nil {
+		m.override.err = err
+		return m, nil
+	}
+	return m, tea.Batch(m.backend.SetEntryConfig(m.override.ids, cfg), m.closeOverrideEditor())
+}
+
+func (m *Model) updateOverride(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter", "ctrl+s":
+		return m.saveOverride()
+	case "esc":
+		return m, m.closeOverrideEditor()
+	case "ctrl+r":
+		// Drop every override so the entries follow the global config again.
+		for i := range m.override.form.inputs {
+			m.override.form.inputs[i].SetValue("")
+		}
+		return m, nil
+	}
+	m.override.err = nil
+	return m, m.override.form.Update(msg)
+}
+
+func (m Model) renderOverrideEditor() string {
+	var s strings.Builder
+
+	boldStyle := lipgloss.NewStyle().Bold(true)
+	faintStyle := lipgloss.NewStyle().Faint(true)
+	errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	saveBtnStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("46")).
+		Border(lipgloss.RoundedBorder()).
+		Padding(0, 1)
+	cancelBtnStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("241")).
+		Border(lipgloss.RoundedBorder()).
+		Padding(0, 1)
+
+	s.WriteString(boldStyle.Render("Override settings for: "))
+	s.WriteString(m.override.title)
+	s.WriteString("\n")
+	s.WriteString(faintStyle.Render("  Leave a field empty to use the global setting."))
+	s.WriteString("\n\n")
+	s.WriteString(m.override.form.View(overrideFormName))
+	s.WriteString("\n")
+
+	saveBtn := zone.Mark(zoneOverrideSave, saveBtnStyle.Render("✓ Apply"))
+	cancelBtn := zone.Mark(zoneOverrideCancel, cancelBtnStyle.Render("✕ Cancel"))
+	s.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, "  ", saveBtn, "  ", cancelBtn))
+	s.WriteString("\n")
+
+	if m.override.err != nil {
+		s.WriteString(errorStyle.Render("  ✗ " + m.override.err.Error()))
+		s.WriteString("\n")
+	}
+	return s.String()
+}
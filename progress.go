@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// progressPrefix marks the lines printed through progressTemplate, so they
+// can't be confused with anything else yt-dlp writes to stdout.
+const progressPrefix = "mldy-progress"
+
+// progressTemplate makes yt-dlp print each progress update as one
+// space-separated line. yt-dlp prints NA for unknown values; the title goes
+// last because it may contain spaces.
+const progressTemplate = "download:" + progressPrefix +
+	" %(progress.status)s" +
+	" %(progress.downloaded_bytes)s" +
+	" %(progress.total_bytes)s" +
+	" %(progress.total_bytes_estimate)s" +
+	" %(progress.speed)s" +
+	" %(progress.eta)s" +
+	" %(info.title)s"
+
+// transferProgress is one parsed progress line. Unknown numbers are zero.
+type transferProgress struct {
+	Status     string // "downloading" or "finished"
+	Downloaded int64
+	Total      int64 // exact size, or yt-dlp's estimate when it has none
+	Speed      float64
+	ETA        time.Duration
+	Title      string
+}
+
+// parseProgressLine parses a line printed through progressTemplate.
+func parseProgressLine(line string) (transferProgress, bool) {
+	rest, ok := strings.CutPrefix(line, progressPrefix+" ")
+	if !ok {
+		return transferProgress{}, false
+	}
+	fields := strings.SplitN(rest, " ", 7)
+	if len(fields) < 6 {
+		return transferProgress{}, false
+	}
+
+	p := transferProgress{
+		Status:     fields[0],
+		Downloaded: int64(parseProgressNumber(fields[1])),
+		Total:      int64(parseProgressNumber(fields[2])),
+		Speed:      parseProgressNumber(fields[4]),
+		ETA:        time.Duration(parseProgressNumber(fields[5])) * time.Second,
+	}
+	if p.Total == 0 {
+		p.Total = int64(parseProgressNumber(fields[3]))
+	}
+	if len(fields) == 7 && fields[6] != "NA" {
+		p.Title = fields[6]
+	}
+	return p, true
+}
+
+func parseProgressNumber(s string) float64 {
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// Percent is 0–100, or 0 while the size is unknown.
+func (p transferProgress) Percent() float64 {
+	if p.Status == "finished" {
+		return 100
+	}
+	if p.Total <= 0 {
+		return 0
+	}
+	return min(100, float64(p.Downloaded)/float64(p.Total)*100)
+}
+
+// DownloadStage is the phase a running download is in. yt-dlp downloads
+// video and audio as separate streams when merging, then runs its
+// post-processors on the result.
+type DownloadStage int
+
+const (
+	StageNone DownloadStage = iota
+	StageVideo
+	StageAudio
+	StageMerge
+	StageExtractAudio
+	StageEmbedSubtitles
+	StageMetadata
+	StageEmbedThumbnail
+	StageSplitChapters
+	StageSponsorBlock
+)
+
+func (s DownloadStage) String() string {
+	switch s {
+	case StageVideo:
+		return "Downloading video"
+	case StageAudio:
+		return "Downloading audio"
+	case StageMerge:
+		return "Merging"
+	case StageExtractAudio:
+		return "Converting audio"
+	case StageEmbedSubtitles:
+		return "Embedding subtitles"
+	case StageMetadata:
+		return "Writing metadata"
+	case StageEmbedThumbnail:
+		return "Embedding thumbnail"
+	case StageSplitChapters:
+		return "Splitting chapters"
+	case StageSponsorBlock:
+		return "Applying SponsorBlock"
+	default:
+		return "Downloading"
+	}
+}
+
+// MarshalText sends stages by name, like DownloadStatus, so API clients
+// don't depend on the order of the constants.
+func (s DownloadStage) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *DownloadStage) UnmarshalText(text []byte) error {
+	for stage := StageNone; stage <= StageSponsorBlock; stage++ {
+		if stage.String() == string(text) {
+			*s = stage
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown download stage %q", text)
+}
+
+// PostProcessing reports whether the stage runs after all streams are down.
+func (s DownloadStage) PostProcessing() bool {
+	return s >= StageMerge
+}
+
+// postStages maps the line prefixes of yt-dlp's post-processors to stages.
+var postStages = []struct {
+	prefix string
+	stage  DownloadStage
+}{
+	{"[Merger]", StageMerge},
+	{"[ModifyChapters]", StageSponsorBlock},
+	{"[ExtractAudio]", StageExtractAudio},
+	{"[EmbedSubtitle]", StageEmbedSubtitles},
+	{"[Metadata]", StageMetadata},
+	{"[EmbedThumbnail]", StageEmbedThumbnail},
+	{"[SplitChapters]", StageSplitChapters},
+}
+
+// The streams share the first 90% of an entry's bar; each post-processing
+// stage then moves it to a fixed point of the rest, in the order yt-dlp
+// runs them.
+const downloadShare = 90
+
+var postStageProgress = map[DownloadStage]float64{
+	StageMerge:          91,
+	StageSponsorBlock:   92,
+	StageExtractAudio:   93,
+	StageEmbedSubtitles: 94,
+	StageMetadata:       96,
+	StageEmbedThumbnail: 97,
+	StageSplitChapters:  98,
+}
+
+// stageTracker follows one yt-dlp run through its stages and turns the
+// per-stream progress into a single figure that only moves forward.
+type stageTracker struct {
+	audioOnly bool
+	streams   int // from yt-dlp's "Downloading N format(s): 137+140" line
+	stream    int // 1-based; 0 before the first destination line
+	stage     DownloadStage
+	percent   float64
+	totals    []int64 // size of each stream, by stream number
+}
+
+// observe updates the stage from a line of yt-dlp output and reports
+// whether it changed.
+func (t *stageTracker) observe(line string) bool {
+	if strings.HasPrefix(line, "[info] ") {
+		if _, formats, ok := strings.Cut(line, " format(s): "); ok {
+			t.streams = strings.Count(formats, "+") + 1
+		}
+		return false
+	}
+	// A stream finished by an earlier, paused run is reported instead of
+	// downloaded again.
+	if strings.HasPrefix(line, "[download] Destination:") ||
+		(strings.HasPrefix(line, "[download] ") && strings.HasSuffix(line, " has already been downloaded")) {
+		t.stream++
+		stage := StageVideo
+		if t.audioOnly || (t.streams > 1 && t.stream > 1) {
+			stage = StageAudio
+		}
+		return t.set(stage)
+	}
+	for _, ps := range postStages {
+		if strings.HasPrefix(line, ps.prefix) {
+			return t.set(ps.stage)
+		}
+	}
+	return false
+}
+
+func (t *stageTracker) set(stage DownloadStage) bool {
+	if stage == t.stage {
+		return false
+	}
+	t.stage = stage
+	if pct, ok := postStageProgress[stage]; ok {
+		t.percent = max(t.percent, pct)
+	}
+	return true
+}
+
+// progress folds a progress line for the current stream into the entry's
+// overall 0–100 figure.
+func (t *stageTracker) progress(p transferProgress) float64 {
+	for len(t.totals) < max(t.stream, 1) {
+		t.totals = append(t.totals, 0)
+	}
+	t.totals[max(t.stream, 1)-1] = p.Total
+
+	streams := max(1, t.streams)
+	done := float64(min(max(t.stream, 1), streams) - 1)
+	t.percent = max(t.percent, (done+p.Percent()/100)/float64(streams)*downloadShare)
+	return t.percent
+}
+
+// expectedBytes is the combined size of the streams seen so far.
+func (t *stageTracker) expectedBytes() int64 {
+	var sum int64
+	for _, n := range t.totals {
+		sum += n
+	}
+	return sum
+}
+
+// transferSummary renders an entry's byte counts, speed and ETA, e.g.
+// "12.3 MiB / 45.6 MiB  •  2.1 MiB/s  •  ETA 15s". Parts that aren't known
+// yet are left out.
+func transferSummary(e DownloadEntry) string {
+	var parts []string
+	switch {
+	case e.TotalBytes > 0:
+		parts = append(parts, formatBytes(e.DownloadedBytes)+" / "+formatBytes(e.TotalBytes))
+	case e.DownloadedBytes > 0:
+		parts = append(parts, formatBytes(e.DownloadedBytes))
+	}
+	if e.Status == StatusDownloading && !e.Stage.PostProcessing() {
+		if e.Speed > 0 {
+			parts = append(parts, formatBytes(int64(e.Speed))+"/s")
+		}
+		if e.ETA > 0 {
+			parts = append(parts, fmt.Sprintf("ETA %s", e.ETA))
+		}
+	}
+	return strings.Join(parts, "  •  ")
+}
@@ -12,6 +12,9 @@ const (
 	StatusDownloading
 	StatusCompleted
 	StatusFailed
+	StatusPaused
+	StatusCanceled
+	StatusSkipped // already in the download archive
 )
 
 func (s DownloadStatus) String() string {
@@ -24,33 +27,92 @@ func (s DownloadStatus) String() string {
 		return "Completed"
 	case StatusFailed:
 		return "Failed"
+	case StatusPaused:
+		return "Paused"
+	case StatusCanceled:
+		return "Canceled"
+	case StatusSkipped:
+		return "Skipped"
 	default:
 		return "Unknown"
 	}
 }
 
+// MarshalText stores statuses by name so persisted files stay readable and
+// don't depend on the order of the constants above.
+func (s DownloadStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *DownloadStatus) UnmarshalText(text []byte) error {
+	for status := StatusQueued; status.String() != "Unknown"; status++ {
+		if status.String() == string(text) {
+			*s = status
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown download status %q", text)
+}
+
 // PlaylistMeta is set on entries that were expanded from a playlist.
 type PlaylistMeta struct {
-	PlaylistTitle string
-	Index         int // 1-based position within the playlist
-	Total         int // total number of items in the playlist
+	PlaylistTitle string `yaml:"playlist_title"`
+	Index         int    `yaml:"index"` // 1-based position within the playlist
+	Total         int    `yaml:"total"` // total number of items in the playlist
 }
 
 type DownloadEntry struct {
-	ID       int
-	URL      string
-	Title    string
-	Status   DownloadStatus
-	Progress float64
-	Error    string
-	Config   EntryConfig
+	ID       int            `yaml:"id"`
+	URL      string         `yaml:"url"`
+	Title    string         `yaml:"title,omitempty"`
+	Status   DownloadStatus `yaml:"status"`
+	Progress float64        `yaml:"-"`
+	Error    string         `yaml:"error,omitempty"`
+	Config   EntryConfig    `yaml:"config"`
+
+	// ArchiveID is the entry's line in the download archive, when known.
+	ArchiveID string `yaml:"archive_id,omitempty"`
 
 	// Non-nil when this entry was expanded from a playlist.
-	Playlist *PlaylistMeta
+	Playlist *PlaylistMeta `yaml:"playlist,omitempty"`
+
+	// Duration in seconds comes from resolving; SizeBytes from resolving
+	// or the latest progress update. Overall progress is weighted by them.
+	Duration  float64 `yaml:"duration,omitempty"`
+	SizeBytes int64   `yaml:"-"`
 
-	StartTime  time.Time
-	EndTime    time.Time
-	OutputPath string
+	StartTime  time.Time `yaml:"start_time,omitempty"`
+	EndTime    time.Time `yaml:"end_time,omitempty"`
+	OutputPath string    `yaml:"output_path,omitempty"`
+
+	// OutputFiles are the per-chapter files split from OutputPath.
+	OutputFiles []string `yaml:"output_files,omitempty"`
+
+	// SponsorBlock segments marked or removed, and the seconds they cover.
+	SponsorSegments int     `yaml:"sponsor_segments,omitempty"`
+	SponsorSeconds  float64 `yaml:"sponsor_seconds,omitempty"`
+
+	// Subtitles saved next to OutputPath, the languages embedded in it and
+	// those that went missing.
+	SubtitleFiles     []string `yaml:"subtitle_files,omitempty"`
+	EmbeddedSubtitles []string `yaml:"embedded_subtitles,omitempty"`
+	MissingSubtitles  []string `yaml:"missing_subtitles,omitempty"`
+
+	// Transfer details from the latest progress update; live only.
+	DownloadedBytes int64         `yaml:"-"`
+	TotalBytes      int64         `yaml:"-"`
+	Speed           float64       `yaml:"-"`
+	ETA             time.Duration `yaml:"-"`
+	Stage           DownloadStage `yaml:"-"`
+
+	// PartialFiles are the files a paused download left behind, so resuming
+	// can continue them and canceling can clean them up.
+	PartialFiles []string `yaml:"partial_files,omitempty"`
+
+	// Attempts records every finished run of yt-dlp for this entry. While
+	// RetryAt is in the future a failed entry waits in the queue.
+	Attempts []DownloadAttempt `yaml:"attempts,omitempty"`
+	RetryAt  time.Time         `yaml:"retry_at,omitempty"`
 }
 
 // DisplayTitle returns the best available label for UI display.
@@ -72,6 +134,11 @@ func (e *DownloadEntry) PlaylistLabel() string {
 type Queue struct {
 	Entries []DownloadEntry
 	nextId  int
+
+	// path is where the queue is persisted; empty keeps it in memory only.
+	path      string
+	lastSaved []byte
+	saveErr   error
 }
 
 func NewQueue() *Queue {
@@ -81,33 +148,51 @@ func NewQueue() *Queue {
 	}
 }
 
-func (q *Queue) add(url, title string, playlist *PlaylistMeta, config EntryConfig) {
+// add appends an entry, marking it skipped when skip (which may be nil)
+// has it archived.
+func (q *Queue) add(item PlaylistItem, playlist *PlaylistMeta, config EntryConfig, skip *Archive) {
+	status := StatusQueued
+	if skip.Contains(item.ArchiveID) {
+		status = StatusSkipped
+	}
 	q.Entries = append(q.Entries, DownloadEntry{
-		ID:       q.nextId,
-		URL:      url,
-		Title:    title,
-		Status:   StatusQueued,
-		Config:   config,
-		Playlist: playlist,
+		ID:        q.nextId,
+		URL:       item.URL,
+		Title:     item.Title,
+		Status:    status,
+		Config:    config,
+		ArchiveID: item.ArchiveID,
+		Playlist:  playlist,
+		Duration:  item.Duration,
+		SizeBytes: item.Size,
 	})
 	q.nextId++
 }
 
 // Add queues a single video URL.
 func (q *Queue) Add(url string, config EntryConfig) {
-	q.add(url, "", nil, config)
+	q.AddItem(PlaylistItem{URL: url}, config, nil)
 }
 
-// AddPlaylistItems expands a resolved playlist into individual queue entries.
-func (q *Queue) AddPlaylistItems(items []PlaylistItem, playlistTitle string, config EntryConfig) {
+// AddItem queues a single resolved video, or marks it skipped when skip
+// is non-nil and has it archived.
+func (q *Queue) AddItem(item PlaylistItem, config EntryConfig, skip *Archive) {
+	q.add(item, nil, config, skip)
+	q.save()
+}
+
+// AddPlaylistItems expands a resolved playlist into individual queue
+// entries. With a non-nil skip, items it has archived are marked skipped.
+func (q *Queue) AddPlaylistItems(items []PlaylistItem, playlistTitle string, config EntryConfig, skip *Archive) {
 	total := len(items)
 	for i, item := range items {
-		q.add(item.URL, item.Title, &PlaylistMeta{
+		q.add(item, &PlaylistMeta{
 			PlaylistTitle: playlistTitle,
 			Index:         i + 1,
 			Total:         total,
-		}, config)
+		}, config, skip)
 	}
+	q.save()
 }
 
 func (q *Queue) GetQueued() []DownloadEntry {
@@ -130,10 +215,35 @@ func (q *Queue) GetActive() []DownloadEntry {
 	return out
 }
 
+// GetInProgress returns entries that have started but not finished, i.e.
+// downloading or paused, in queue order.
+func (q *Queue) GetInProgress() []DownloadEntry {
+	var out []DownloadEntry
+	for _, e := range q.Entries {
+		if e.Status == StatusDownloading || e.Status == StatusPaused {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
 func (q *Queue) GetCompleted() []DownloadEntry {
 	var out []DownloadEntry
 	for _, e := range q.Entries {
-		if e.Status == StatusCompleted || e.Status == StatusFailed {
+		switch e.Status {
+		case StatusCompleted, StatusFailed, StatusCanceled, StatusSkipped:
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// GetSkipped returns the entries skipped because they were downloaded
+// before.
+func (q *Queue) GetSkipped() []DownloadEntry {
+	var out []DownloadEntry
+	for _, e := range q.Entries {
+		if e.Status == StatusSkipped {
 			out = append(out, e)
 		}
 	}
@@ -144,6 +254,7 @@ func (q *Queue) Update(id int, fn func(*DownloadEntry)) {
 	for i := range q.Entries {
 		if q.Entries[i].ID == id {
 			fn(&q.Entries[i])
+			q.save()
 			return
 		}
 	}
@@ -162,25 +273,103 @@ func (q *Queue) Remove(id int) {
 	for i, e := range q.Entries {
 		if e.ID == id {
 			q.Entries = append(q.Entries[:i], q.Entries[i+1:]...)
+			q.save()
 			return
 		}
 	}
 }
 
+// assumedByteRate sizes entries by duration until a real download shows
+// how many bytes a second of media takes (128 kbit/s).
+const assumedByteRate = 16000
+
+// expectedSizes estimates each entry's size in bytes, aligned with
+// q.Entries. Failed, canceled and skipped entries weigh nothing; entries with
+// neither a size nor a duration count as an average one.
+func (q *Queue) expectedSizes() []float64 {
+	var knownBytes, knownSeconds float64
+	for _, e := range q.Entries {
+		if e.SizeBytes > 0 && e.Duration > 0 {
+			knownBytes += float64(e.SizeBytes)
+			knownSeconds += e.Duration
+		}
+	}
+	rate := float64(assumedByteRate)
+	if knownSeconds > 0 {
+		rate = knownBytes / knownSeconds
+	}
+
+	sizes := make([]float64, len(q.Entries))
+	var sum float64
+	var n int
+	for i, e := range q.Entries {
+		switch {
+		case !e.counted():
+			continue
+		case e.SizeBytes > 0:
+			sizes[i] = float64(e.SizeBytes)
+		case e.Duration > 0:
+			sizes[i] = e.Duration * rate
+		default:
+			continue
+		}
+		sum += sizes[i]
+		n++
+	}
+
+	mean := 1.0
+	if n > 0 {
+		mean = sum / float64(n)
+	}
+	for i, e := range q.Entries {
+		if sizes[i] == 0 && e.counted() {
+			sizes[i] = mean
+		}
+	}
+	return sizes
+}
+
+// counted reports whether the entry is part of the work the queue does.
+func (e *DownloadEntry) counted() bool {
+	return e.Status != StatusFailed && e.Status != StatusCanceled && e.Status != StatusSkipped
+}
+
+// byteProgress returns the estimated bytes done and in total.
+func (q *Queue) byteProgress() (done, total float64) {
+	for i, size := range q.expectedSizes() {
+		total += size
+		switch e := q.Entries[i]; e.Status {
+		case StatusCompleted:
+			done += size
+		case StatusDownloading, StatusPaused:
+			done += size * e.Progress / 100
+		}
+	}
+	return done, total
+}
+
+// TotalProgress is the share of the queue's estimated bytes that is done,
+// 0–100.
 func (q *Queue) TotalProgress() float64 {
-	if len(q.Entries) == 0 {
+	done, total := q.byteProgress()
+	if total == 0 {
 		return 0
 	}
-	var total float64
+	return done / total * 100
+}
+
+// Remaining estimates how long the rest of the queue takes at the current
+// combined download speed; ok is false while nothing is transferring.
+func (q *Queue) Remaining() (time.Duration, bool) {
+	var speed float64
 	for _, e := range q.Entries {
-		switch e.Status {
-		case StatusCompleted:
-			total += 100
-		case StatusDownloading:
-			total += e.Progress
-		default: // StatusFailed, StatusQueued
-			// count as 0% progress
+		if e.Status == StatusDownloading && !e.Stage.PostProcessing() {
+			speed += e.Speed
 		}
 	}
-	return total / float64(len(q.Entries))
+	if speed <= 0 {
+		return 0, false
+	}
+	done, total := q.byteProgress()
+	return time.Duration((total - done) / speed * float64(time.Second)), true
 }
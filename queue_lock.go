@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// errQueueLocked means another mldy process works on the queue.
+var errQueueLocked = errors.New("the queue is in use by another mldy")
+
+// queueLockFile holds the queue lock once taken. Keeping it referenced
+// keeps the file open, and so locked, until the process exits.
+var queueLockFile *os.File
+
+// lockQueue makes this process the only one working on queue.yaml until it
+// exits. history.jsonl and archive.txt are only ever appended to, so `mldy
+// get` adds to them without taking the lock. owner is written into the
+// lock file: the daemon puts its address there, the TUI nothing. When the
+// lock is taken it returns errQueueLocked and the holder's owner.
+func lockQueue(owner string) (holder string, err error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, "queue.lock"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return "", err
+	}
+	if err := lockFile(f); err != nil {
+		data, _ := io.ReadAll(f)
+		f.Close()
+		return strings.TrimSpace(string(data)), err
+	}
+	f.Truncate(0)
+	f.WriteAt([]byte(owner+"\n"), 0)
+	queueLockFile = f
+	return "", nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+// queueFile is the on-disk layout of a persisted Queue.
+type queueFile struct {
+	NextID  int             `yaml:"next_id"`
+	Entries []DownloadEntry `yaml:"entries"`
+}
+
+// queuePath returns ~/.config/mldy/queue.yaml.
+func queuePath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "queue.yaml"), nil
+}
+
+// OpenQueue restores the queue persisted at path and keeps writing every
+// later mutation back to it. A missing file yields an empty queue.
+//
+// Only unfinished entries are restored. Entries that were still downloading
+// when mldy exited go back to queued; yt-dlp continues their .part files.
+func OpenQueue(path string) (*Queue, error) {
+	q := NewQueue()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		q.path = path
+		return q, nil
+	}
+	if err != nil {
+		return q, q.unreadable(path, err)
+	}
+
+	var file queueFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		// Leave the broken file alone rather than overwriting it.
+		return q, q.unreadable(path, err)
+	}
+
+	for _, e := range file.Entries {
+		switch e.Status {
+		case StatusDownloading:
+			e.Status = StatusQueued
+		case StatusQueued, StatusPaused:
+		default:
+			continue
+		}
+		// Nothing is left to wake up a pending retry, so it just goes first.
+		e.RetryAt = time.Time{}
+		q.Entries = append(q.Entries, e)
+	}
+	q.nextId = max(file.NextID, 1)
+	for _, e := range q.Entries {
+		q.nextId = max(q.nextId, e.ID+1)
+	}
+
+	q.path = path
+	q.save()
+	return q, q.saveErr
+}
+
+// unreadable records that the queue at path couldn't be restored. The queue
+// then has no path and isn't saved, which SaveError keeps reporting.
+func (q *Queue) unreadable(path string, err error) error {
+	q.saveErr = fmt.Errorf("%s can't be read, so changes aren't saved: %w", path, err)
+	return err
+}
+
+// save writes the queue to disk if it has a path and its persisted state
+// changed since the last write, so progress-only updates never hit the disk.
+func (q *Queue) save() {
+	if q.path == "" {
+		return
+	}
+
+	data, err := yaml.Marshal(queueFile{NextID: q.nextId, Entries: q.Entries})
+	if err != nil {
+		q.saveErr = err
+		return
+	}
+	if bytes.Equal(data, q.lastSaved) {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(q.path), 0755); err != nil {
+		q.saveErr = err
+		return
+	}
+	// Write to a temp file and rename so a crash mid-write can't leave a
+	// truncated queue behind.
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		q.saveErr = err
+		return
+	}
+	if err := os.Rename(tmp, q.path); err != nil {
+		q.saveErr = err
+		return
+	}
+
+	q.lastSaved = data
+	q.saveErr = nil
+}
+
+// SaveError returns the error of the most recent failed write, if any.
+func (q *Queue) SaveError() error {
+	return q.saveErr
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/goccy/go-yaml"
+)
+
+// remoteReconnectDelay is how long the event stream waits before
+// reconnecting to a daemon that went away.
+const remoteReconnectDelay = 2 * time.Second
+
+// remoteBackend drives the queue of a `mldy serve` daemon. It mirrors the
+// daemon's queue from the event stream, so the screens read it like a local
+// one; downloads keep going when the TUI quits.
+type remoteBackend struct {
+	base   string // e.g. http://127.0.0.1:8765, without a trailing slash
+	token  string
+	client *http.Client
+
+	config     Config
+	queue      *Queue // never saved; the daemon persists its own
+	history    *History
+	historyErr error
+	running    bool
+	resolving  int
+
+	// streamErr is set while the event stream is down, actionErr by the
+	// last request the daemon refused.
+	streamErr error
+	actionErr error
+
+	// events carries messages from the stream goroutine to Update.
+	events chan tea.Msg
+}
+
+// Messages produced by the event stream and by requests to the daemon.
+type (
+	remoteEntriesMsg   []apiEntry
+	remoteEntryMsg     apiEntry
+	remoteRemovedMsg   struct{ ID int }
+	remoteStatusMsg    apiStatus
+	remoteConfigMsg    Config
+	remoteStreamErrMsg struct{ err error }
+	remoteHistoryMsg   struct {
+		records []HistoryRecord
+		err     error
+	}
+	remoteDoneMsg struct{ err error }
+)
+
+// connectRemote checks that a daemon answers at rawURL and fetches its
+// config, so the TUI starts with the settings downloads will use.
+func connectRemote(rawURL, token string) (*remoteBackend, error) {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid daemon address %q", rawURL)
+	}
+	b := &remoteBackend{
+		base:    strings.TrimSuffix(u.String(), "/"),
+		token:   token,
+		client:  &http.Client{},
+		queue:   NewQueue(),
+		history: &History{},
+		events:  make(chan tea.Msg, 64),
+	}
+	if err := b.do(http.MethodGet, "/api/config", nil, &b.config); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *remoteBackend) Config() Config        { return b.config }
+func (b *remoteBackend) Queue() *Queue         { return b.queue }
+func (b *remoteBackend) History() *History     { return b.history }
+func (b *remoteBackend) HistoryErr() error     { return b.historyErr }
+func (b *remoteBackend) Running() bool         { return b.running }
+func (b *remoteBackend) Resolving() int        { return b.resolving }
+func (b *remoteBackend) Location() string      { return b.base }
+func (b *remoteBackend) Close()                {}
+func (b *remoteBackend) Start() tea.Cmd        { return b.post("/api/start", nil) }
+func (b *remoteBackend) Pause(id int) tea.Cmd  { return b.entryAction(id, "pause") }
+func (b *remoteBackend) Resume(id int) tea.Cmd { return b.entryAction(id, "resume") }
+func (b *remoteBackend) Cancel(id int) tea.Cmd { return b.entryAction(id, "cancel") }
+
+// Redownload re-queues a skipped entry; the daemon treats it like a retry.
+func (b *remoteBackend) Redownload(id int) tea.Cmd { return b.entryAction(id, "retry") }
+
+// Remove cancels a queued entry, which the daemon drops from its queue.
+func (b *remoteBackend) Remove(id int) tea.Cmd {
+	b.queue.Remove(id)
+	return b.entryAction(id, "cancel")
+}
+
+// Err reports a lost connection before a refused request.
+func (b *remoteBackend) Err() error {
+	if b.streamErr != nil {
+		return b.streamErr
+	}
+	return b.actionErr
+}
+
+func (b *remoteBackend) Init() tea.Cmd {
+	go b.stream()
+	return tea.Batch(b.listen(), b.fetchHistory())
+}
+
+func (b *remoteBackend) Update(msg tea.Msg) (tea.Cmd, bool) {
+	switch msg := msg.(type) {
+	case remoteEntriesMsg:
+		b.streamErr = nil
+		b.queue.Entries = b.queue.Entries[:0]
+		for _, e := range msg {
+			b.queue.Entries = append(b.queue.Entries, e.entry())
+		}
+		return b.listen(), true
+
+	case remoteEntryMsg:
+		entry := apiEntry(msg).entry()
+		var cmd tea.Cmd
+		if old := b.queue.GetByID(entry.ID); old != nil {
+			// A download that just finished has gone into the history.
+			if old.Status != entry.Status && isFinished(entry.Status) {
+				cmd = b.fetchHistory()
+			}
+			*old = entry
+		} else {
+			b.queue.Entries = append(b.queue.Entries, entry)
+		}
+		return tea.Batch(b.listen(), cmd), true
+
+	case remoteRemovedMsg:
+		b.queue.Remove(msg.ID)
+		return b.listen(), true
+
+	case remoteStatusMsg:
+		b.running, b.resolving = msg.Running, msg.Resolving
+		return b.listen(), true
+
+	case remoteConfigMsg:
+		b.config = Config(msg)
+		return b.listen(), true
+
+	case remoteStreamErrMsg:
+		b.streamErr = msg.err
+		return b.listen(), true
+
+	case remoteHistoryMsg:
+		if msg.err != nil {
+			b.historyErr = msg.err
+			return nil, true
+		}
+		// The daemon sends newest first; History keeps them oldest first.
+		slices.Reverse(msg.records)
+		b.history, b.historyErr = &History{records: msg.records}, nil
+		return nil, true
+
+	case remoteDoneMsg:
+		b.actionErr = msg.err
+		return nil, true
+	}
+	return nil, false
+}
+
+func isFinished(s DownloadStatus) bool {
+	return s == StatusCompleted || s == StatusFailed || s == StatusCanceled
+}
+
+// Add sends URLs to the daemon, which resolves them into entries; they show
+// up through the event stream.
+func (b *remoteBackend) Add(urls []string, config EntryConfig) tea.Cmd {
+	if len(urls) == 0 {
+		return nil
+	}
+	return b.post("/api/entries", map[string]any{"urls": urls, "config": config})
+}
+
+// SetEntryConfig replaces the override of queued entries, one request each;
+// the daemon refuses entries that have started in the meantime.
+func (b *remoteBackend) SetEntryConfig(ids []int, config EntryConfig) tea.Cmd {
+	var cmds []tea.Cmd
+	for _, id := range ids {
+		b.queue.Update(id, func(e *DownloadEntry) {
+			if e.Status == StatusQueued {
+				e.Config = config
+			}
+		})
+		cmds = append(cmds, b.send(http.MethodPut, fmt.Sprintf("/api/entries/%d/config", id), config))
+	}
+	return tea.Batch(cmds...)
+}
+
+// SetConfig shows the new config right away and has the daemon save it.
+// Cookie sources and network settings aren't sent, so the daemon keeps its
+// own.
+func (b *remoteBackend) SetConfig(config Config) (tea.Cmd, error) {
+	config = apiConfig(config)
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	b.config = config
+	return b.send(http.MethodPut, "/api/config", config), nil
+}
+
+func (b *remoteBackend) entryAction(id int, action string) tea.Cmd {
+	return b.post(fmt.Sprintf("/api/entries/%d/%s", id, action), nil)
+}
+
+func (b *remoteBackend) post(path string, body any) tea.Cmd {
+	return b.send(http.MethodPost, path, body)
+}
+
+// send makes a request in the background. Changes it causes arrive through
+// the event stream; only errors are reported back.
+func (b *remoteBackend) send(method, path string, body any) tea.Cmd {
+	return func() tea.Msg {
+		return remoteDoneMsg{err: b.do(method, path, body, nil)}
+	}
+}
+
+func (b *remoteBackend) fetchHistory() tea.Cmd {
+	return func() tea.Msg {
+		var records []HistoryRecord
+		err := b.do(http.MethodGet, "/api/history?limit=0", nil, &records)
+		return remoteHistoryMsg{records: records, err: err}
+	}
+}
+
+// listen waits for the next message from the event stream.
+func (b *remoteBackend) listen() tea.Cmd {
+	return listenProgress(b.events)
+}
+
+// do sends a JSON request and decodes the JSON response into out, which may
+// be nil. Error responses become errors carrying the daemon's message.
+func (b *remoteBackend) do(method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := yaml.MarshalWithOptions(body, yaml.JSON())
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, b.base+path, reader)
+	if err != nil {
+		return err
+	}
+	b.authorize(req)
+	// The daemon takes changes only as JSON, even without a body.
+	if method != http.MethodGet {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `yaml:"error"`
+		}
+		if yaml.Unmarshal(data, &apiErr) == nil && apiErr.Error != "" {
+			return errors.New(apiErr.Error)
+		}
+		return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return yaml.Unmarshal(data, out)
+}
+
+func (b *remoteBackend) authorize(req *http.Request) {
+	if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+}
+
+// stream follows the daemon's event stream for the rest of the session,
+// reconnecting whenever it drops. Every connect starts with a snapshot of
+// the whole queue, so nothing missed in between stays stale.
+func (b *remoteBackend) stream() {
+	for {
+		err := b.readEvents()
+		if err == nil {
+			err = errors.New("daemon closed the connection")
+		}
+		b.events <- remoteStreamErrMsg{err: fmt.Errorf("lost connection to %s: %w", b.base, err)}
+		time.Sleep(remoteReconnectDelay)
+	}
+}
+
+// readEvents reads one connection's events until it ends.
+func (b *remoteBackend) readEvents() error {
+	req, err := http.NewRequest(http.MethodGet, b.base+"/api/events", nil)
+	if err != nil {
+		return err
+	}
+	b.authorize(req)
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(resp.Status)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64<<20)
+	var event string
+	var data []byte
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = append(data, strings.TrimPrefix(line, "data: ")...)
+		case line == "":
+			if msg := decodeEvent(event, data); msg != nil {
+				b.events <- msg
+			}
+			event, data = "", nil
+		}
+	}
+	return scanner.Err()
+}
+
+// decodeEvent turns a server-sent event into the message Update applies,
+// or nil for events it doesn't know.
+func decodeEvent(event string, data []byte) tea.Msg {
+	var msg tea.Msg
+	var err error
+	switch event {
+	case "entries":
+		var entries []apiEntry
+		err = yaml.Unmarshal(data, &entries)
+		msg = remoteEntriesMsg(entries)
+	case "entry":
+		var entry apiEntry
+		err = yaml.Unmarshal(data, &entry)
+		msg = remoteEntryMsg(entry)
+	case "removed":
+		var removed struct {
+			ID int `yaml:"id"`
+		}
+		err = yaml.Unmarshal(data, &removed)
+		msg = remoteRemovedMsg{ID: removed.ID}
+	case "status":
+		var status apiStatus
+		err = yaml.Unmarshal(data, &status)
+		msg = remoteStatusMsg(status)
+	case "config":
+		var config Config
+		err = yaml.Unmarshal(data, &config)
+		msg = remoteConfigMsg(config)
+	default:
+		return nil
+	}
+	if err != nil {
+		return remoteStreamErrMsg{err: fmt.Errorf("bad %q event: %w", event, err)}
+	}
+	return msg
+}
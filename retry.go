@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrorClass tells whether a failed download is worth retrying.
+type ErrorClass int
+
+const (
+	ErrorUnknown   ErrorClass = iota
+	ErrorTransient            // network trouble, throttling, server errors
+	ErrorPermanent            // the video itself can't be downloaded
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorTransient:
+		return "transient"
+	case ErrorPermanent:
+		return "permanent"
+	default:
+		return "unknown"
+	}
+}
+
+func (c ErrorClass) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+func (c *ErrorClass) UnmarshalText(text []byte) error {
+	for _, class := range []ErrorClass{ErrorUnknown, ErrorTransient, ErrorPermanent} {
+		if class.String() == string(text) {
+			*c = class
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown error class %q", text)
+}
+
+// Substrings of yt-dlp's stderr (lowercased) that identify each class.
+// Permanent patterns are checked first: a removed video can also produce a
+// generic HTTP error further down the output.
+var (
+	permanentErrorPatterns = []string{
+		"private video",
+		"video unavailable",
+		"this video has been removed",
+		"has been terminated",
+		"no longer available",
+		"not available in your country",
+		"blocked it in your country",
+		"geo restriction",
+		"geo-restricted",
+		"members-only",
+		"join this channel",
+		"sign in to confirm your age",
+		"unsupported url",
+		"is not a valid url",
+		"http error 404",
+		"http error 410",
+	}
+	transientErrorPatterns = []string{
+		"http error 429",
+		"too many requests",
+		"http error 500",
+		"http error 502",
+		"http error 503",
+		"http error 504",
+		"timed out",
+		"timeout",
+		"fragment not found",
+		"connection reset",
+		"connection refused",
+		"connection aborted",
+		"remote end closed connection",
+		"temporary failure in name resolution",
+		"incompleteread",
+		"unable to download video data",
+		"rate-limited",
+		"throttl",
+	}
+)
+
+// classifyError sorts a failed yt-dlp run into transient or permanent
+// failures based on its error output.
+func classifyError(output string) ErrorClass {
+	out := strings.ToLower(output)
+	for _, p := range permanentErrorPatterns {
+		if strings.Contains(out, p) {
+			return ErrorPermanent
+		}
+	}
+	for _, p := range transientErrorPatterns {
+		if strings.Contains(out, p) {
+			return ErrorTransient
+		}
+	}
+	return ErrorUnknown
+}
+
+// RetryConfig controls automatic retries of failed downloads.
+type RetryConfig struct {
+	// MaxAttempts counts the first try too, so 1 disables retries.
+	MaxAttempts int `yaml:"max_attempts"`
+	// BackoffSeconds is the wait before the first retry; it doubles after
+	// every further failure, up to MaxBackoffSeconds.
+	BackoffSeconds    int `yaml:"backoff_seconds"`
+	MaxBackoffSeconds int `yaml:"max_backoff_seconds"`
+}
+
+// ShouldRetry reports whether another attempt is allowed after `attempts`
+// failed ones ended with an error of the given class.
+func (r RetryConfig) ShouldRetry(class ErrorClass, attempts int) bool {
+	return class == ErrorTransient && attempts < r.MaxAttempts
+}
+
+// Backoff returns how long to wait after the given number of failed attempts.
+func (r RetryConfig) Backoff(attempts int) time.Duration {
+	delay := time.Duration(r.BackoffSeconds) * time.Second
+	limit := time.Duration(r.MaxBackoffSeconds) * time.Second
+	for i := 1; i < attempts && (limit == 0 || delay < limit); i++ {
+		delay *= 2
+	}
+	if limit > 0 {
+		delay = min(delay, limit)
+	}
+	return delay
+}
+
+// DownloadAttempt is one run of yt-dlp for an entry.
+type DownloadAttempt struct {
+	StartTime time.Time  `yaml:"start_time"`
+	EndTime   time.Time  `yaml:"end_time"`
+	Error     string     `yaml:"error,omitempty"`
+	Class     ErrorClass `yaml:"class,omitempty"`
+}
+
+// RetryDueMsg fires when an entry's backoff has elapsed.
+type RetryDueMsg struct {
+	ID int
+}
@@ -0,0 +1,857 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/goccy/go-yaml"
+)
+
+// defaultServeAddr keeps the API private to this machine unless -addr
+// says otherwise.
+const defaultServeAddr = "127.0.0.1:8765"
+
+type serveOptions struct {
+	addr  string
+	token string
+}
+
+func parseServeArgs(args []string, stderr io.Writer) (serveOptions, error) {
+	var opts serveOptions
+
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: mldy serve [flags]")
+		fmt.Fprintln(stderr, "\nRuns the download queue in the background and serves an HTTP/JSON API for it.")
+		fmt.Fprintln(stderr, "\nFlags:")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&opts.addr, "addr", defaultServeAddr, "address to listen on")
+	fs.StringVar(&opts.token, "token", os.Getenv("MLDY_TOKEN"), "require this bearer token on every request (default $MLDY_TOKEN)")
+
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return opts, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	return opts, nil
+}
+
+// runServe implements `mldy serve`: it works through the persisted queue
+// like the TUI does and exposes it over HTTP until interrupted.
+func runServe(args []string) int {
+	opts, err := parseServeArgs(args, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "mldy serve:", err)
+		return exitUsage
+	}
+
+	if holder, err := lockQueue("http://" + opts.addr); err != nil {
+		if errors.Is(err, errQueueLocked) && holder != "" {
+			err = fmt.Errorf("another mldy serve at %s already works on the queue", holder)
+		} else if errors.Is(err, errQueueLocked) {
+			err = errors.New("the TUI is running and works on the queue; quit it first")
+		}
+		fmt.Fprintln(os.Stderr, "mldy serve:", err)
+		return exitAllFailed
+	}
+
+	config, _ := loadConfig()
+	if _, err := exec.LookPath("yt-dlp"); err != nil {
+		fmt.Fprintln(os.Stderr, "mldy serve: yt-dlp not found; run mldy once interactively to install it")
+		return exitAllFailed
+	}
+	runtime, _, _ := detectRuntime()
+
+	queue := NewQueue()
+	if path, err := queuePath(); err == nil {
+		if queue, err = OpenQueue(path); err != nil {
+			fmt.Fprintln(os.Stderr, "mldy serve: could not restore the queue:", err)
+		}
+	}
+	history := &History{}
+	if path, err := historyPath(); err == nil {
+		history, _ = OpenHistory(path)
+	}
+	var archive *Archive
+	if path, err := archivePath(); err == nil {
+		archive = OpenArchive(path)
+	}
+
+	engine := NewEngine(config, queue, NewDownloader(config, runtime, archive.Path()), history, archive)
+	s := newServer(engine, opts.token, os.Stdout)
+
+	listener, err := net.Listen("tcp", opts.addr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "mldy serve:", err)
+		return exitUsage
+	}
+	host, _, _ := net.SplitHostPort(opts.addr)
+	s.loopback = isLoopback(host)
+	if opts.token == "" && !s.loopback {
+		fmt.Fprintln(os.Stderr, "mldy serve: warning: listening beyond localhost without -token; anyone who can reach it can queue downloads")
+	}
+	fmt.Printf("mldy serve: listening on http://%s\n", listener.Addr())
+
+	srv := &http.Server{Handler: s.handler()}
+	go s.loop()
+	// Entries restored from an earlier run pick up where they left off.
+	s.call(engine.Start)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		fmt.Println("mldy serve: shutting down")
+		close(s.quit)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintln(os.Stderr, "mldy serve:", err)
+		return exitAllFailed
+	}
+	// Running downloads stay "downloading" in queue.yaml and are resumed
+	// from their .part files on the next start.
+	engine.StopAll()
+	return exitOK
+}
+
+// isLoopback reports whether host only accepts local connections.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// server runs the Engine on a single goroutine, as headlessLoop does for
+// `mldy get`, and lets HTTP handlers act on it through calls. It also
+// publishes every change to an entry to the event stream subscribers.
+type server struct {
+	*headlessLoop
+	token string
+	// loopback is set when listening on localhost only; requests must then
+	// name a local Host, which DNS rebinding can't fake.
+	loopback bool
+
+	calls chan serverCall
+	quit  chan struct{}
+
+	// Owned by the loop goroutine.
+	subscribers map[chan []byte]bool
+	published   map[int]string // last event sent per entry
+	lastStatus  apiStatus
+}
+
+type serverCall struct {
+	fn   func() tea.Cmd
+	done chan struct{}
+}
+
+func newServer(engine *Engine, token string, log io.Writer) *server {
+	return &server{
+		headlessLoop: newHeadlessLoop(engine, log),
+		token:        token,
+		calls:        make(chan serverCall),
+		quit:         make(chan struct{}),
+		subscribers:  make(map[chan []byte]bool),
+		published:    make(map[int]string),
+	}
+}
+
+// loop processes engine messages and handler calls until shutdown.
+func (s *server) loop() {
+	for {
+		select {
+		case msg := <-s.msgs:
+			s.drainProgress()
+			s.pending--
+			s.dispatch(msg)
+			s.publish(nil)
+		case msg := <-s.engine.Progress():
+			s.dispatch(msg)
+			if p, ok := msg.(ProgressMsg); ok {
+				s.publish([]int{p.ID})
+			} else {
+				s.publish(nil)
+			}
+		case c := <-s.calls:
+			s.run(c.fn())
+			close(c.done)
+			s.publish(nil)
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// call runs fn on the loop goroutine, runs the command it returns and
+// waits for it to finish. It reports false when the server is shutting
+// down and fn didn't run.
+func (s *server) call(fn func() tea.Cmd) bool {
+	c := serverCall{fn: fn, done: make(chan struct{})}
+	select {
+	case s.calls <- c:
+	case <-s.quit:
+		return false
+	}
+	<-c.done
+	return true
+}
+
+// apiEntry is a queue entry as the API returns it, including the live
+// transfer details the queue file leaves out.
+type apiEntry struct {
+	ID              int               `yaml:"id"`
+	URL             string            `yaml:"url"`
+	Title           string            `yaml:"title,omitempty"`
+	Status          DownloadStatus    `yaml:"status"`
+	Stage           DownloadStage     `yaml:"stage,omitempty"`
+	Progress        float64           `yaml:"progress"`
+	DownloadedBytes int64             `yaml:"downloaded_bytes,omitempty"`
+	TotalBytes      int64             `yaml:"total_bytes,omitempty"`
+	Speed           float64           `yaml:"speed,omitempty"`       // bytes/s
+	ETA             float64           `yaml:"eta_seconds,omitempty"` // seconds
+	Error           string            `yaml:"error,omitempty"`
+	Playlist        *PlaylistMeta     `yaml:"playlist,omitempty"`
+	Config          EntryConfig       `yaml:"config"`
+	Duration        float64           `yaml:"duration,omitempty"`   // seconds
+	SizeBytes       int64             `yaml:"size_bytes,omitempty"` // expected
+	Attempts        []DownloadAttempt `yaml:"attempts,omitempty"`
+	RetryAt         time.Time         `yaml:"retry_at,omitempty"`
+	OutputPath      string            `yaml:"output_path,omitempty"`
+	OutputFiles     []string          `yaml:"output_files,omitempty"`
+}
+
+func newAPIEntry(e DownloadEntry) apiEntry {
+	entry := apiEntry{
+		ID:              e.ID,
+		URL:             e.URL,
+		Title:           e.Title,
+		Status:          e.Status,
+		Progress:        e.Progress,
+		DownloadedBytes: e.DownloadedBytes,
+		TotalBytes:      e.TotalBytes,
+		Speed:           e.Speed,
+		ETA:             e.ETA.Seconds(),
+		Error:           e.Error,
+		Playlist:        e.Playlist,
+		Config:          e.Config,
+		Duration:        e.Duration,
+		SizeBytes:       e.SizeBytes,
+		Attempts:        e.Attempts,
+		RetryAt:         e.RetryAt,
+		OutputPath:      e.OutputPath,
+		OutputFiles:     e.OutputFiles,
+	}
+	if e.Status == StatusDownloading {
+		entry.Stage = e.Stage
+	}
+	return entry
+}
+
+// entry turns an API entry back into a queue entry, for a remote TUI.
+func (a apiEntry) entry() DownloadEntry {
+	return DownloadEntry{
+		ID:              a.ID,
+		URL:             a.URL,
+		Title:           a.Title,
+		Status:          a.Status,
+		Stage:           a.Stage,
+		Progress:        a.Progress,
+		DownloadedBytes: a.DownloadedBytes,
+		TotalBytes:      a.TotalBytes,
+		Speed:           a.Speed,
+		ETA:             time.Duration(a.ETA * float64(time.Second)),
+		Error:           a.Error,
+		Playlist:        a.Playlist,
+		Config:          a.Config,
+		Duration:        a.Duration,
+		SizeBytes:       a.SizeBytes,
+		Attempts:        a.Attempts,
+		RetryAt:         a.RetryAt,
+		OutputPath:      a.OutputPath,
+		OutputFiles:     a.OutputFiles,
+	}
+}
+
+// apiStatus is whether the daemon is working through its queue.
+type apiStatus struct {
+	Running   bool `yaml:"running"`
+	Resolving int  `yaml:"resolving"`
+}
+
+func (s *server) status() apiStatus {
+	return apiStatus{Running: s.engine.isRunning, Resolving: s.engine.resolvingCount}
+}
+
+// apiConfig is the global config as the API shows it. Cookie sources and
+// network settings stay on the server; they may hold paths and passwords.
+func apiConfig(c Config) Config {
+	c.Cookies = nil
+	c.Network = NetworkConfig{}
+	return c
+}
+
+// cloneConfig returns a copy of c that shares no maps or pointers with it.
+func cloneConfig(c Config) (Config, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return c, err
+	}
+	var clone Config
+	err = yaml.Unmarshal(data, &clone)
+	return clone, err
+}
+
+// marshalJSON encodes v as single-line JSON with the field names of its
+// yaml tags, like the history file.
+func marshalJSON(v any) []byte {
+	data, err := yaml.MarshalWithOptions(v, yaml.JSON())
+	if err != nil {
+		data, _ = yaml.MarshalWithOptions(map[string]string{"error": err.Error()}, yaml.JSON())
+	}
+	return []byte(strings.TrimSpace(string(data)))
+}
+
+// sseEvent formats one server-sent event.
+func sseEvent(name string, data []byte) []byte {
+	return fmt.Appendf(nil, "event: %s\ndata: %s\n\n", name, data)
+}
+
+// publish sends an "entry" event for every entry among ids (all when nil)
+// that changed since the last one, a "removed" event for entries gone from
+// the queue and a "status" event when the daemon starts or stops working.
+func (s *server) publish(ids []int) {
+	if len(s.subscribers) == 0 {
+		return
+	}
+	if status := s.status(); status != s.lastStatus {
+		s.lastStatus = status
+		s.broadcast(sseEvent("status", marshalJSON(status)))
+	}
+	if ids == nil {
+		seen := make(map[int]bool, len(s.engine.queue.Entries))
+		for _, e := range s.engine.queue.Entries {
+			seen[e.ID] = true
+			ids = append(ids, e.ID)
+		}
+		for id := range s.published {
+			if !seen[id] {
+				delete(s.published, id)
+				s.broadcast(sseEvent("removed", fmt.Appendf(nil, `{"id": %d}`, id)))
+			}
+		}
+	}
+	for _, id := range ids {
+		e := s.engine.queue.GetByID(id)
+		if e == nil {
+			continue
+		}
+		data := marshalJSON(newAPIEntry(*e))
+		if s.published[id] == string(data) {
+			continue
+		}
+		s.published[id] = string(data)
+		s.broadcast(sseEvent("entry", data))
+	}
+}
+
+// broadcast hands an event to every subscriber. One that has fallen too
+// far behind is dropped; its client can reconnect for a fresh snapshot.
+func (s *server) broadcast(event []byte) {
+	for ch := range s.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/entries", s.listEntries)
+	mux.HandleFunc("POST /api/entries", s.addEntries)
+	mux.HandleFunc("GET /api/entries/{id}", s.getEntry)
+	mux.HandleFunc("PUT /api/entries/{id}/config", s.setEntryConfig)
+	mux.HandleFunc("POST /api/entries/{id}/pause", s.pauseEntry)
+	mux.HandleFunc("POST /api/entries/{id}/resume", s.resumeEntry)
+	mux.HandleFunc("POST /api/entries/{id}/cancel", s.cancelEntry)
+	mux.HandleFunc("POST /api/entries/{id}/retry", s.retryEntry)
+	mux.HandleFunc("POST /api/start", s.start)
+	mux.HandleFunc("GET /api/config", s.getConfig)
+	mux.HandleFunc("PUT /api/config", s.setConfig)
+	mux.HandleFunc("GET /api/history", s.listHistory)
+	mux.HandleFunc("GET /api/events", s.events)
+	return s.guard(s.authorize(mux))
+}
+
+// guard turns away requests a web page could make on the user's behalf:
+// ones for a non-local Host on a loopback server (DNS rebinding), ones
+// from another site's Origin, and changes sent as anything but JSON, which
+// browsers can't post cross-site without a preflight this server refuses.
+func (s *server) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		if s.loopback && !isLoopback(strings.Trim(host, "[]")) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("host %q is not local", r.Host))
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+				writeError(w, http.StatusForbidden, fmt.Errorf("cross-site request from %q", origin))
+				return
+			}
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct != "application/json" {
+				writeError(w, http.StatusUnsupportedMediaType, errors.New("send the request as Content-Type: application/json"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorize requires the token, when one is set, as a bearer token or, for
+// EventSource clients that can't set headers, a token query parameter.
+func (s *server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok {
+				got = r.URL.Query().Get("token")
+			}
+			if subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, http.StatusUnauthorized, errors.New("missing or wrong token"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// readBody decodes a JSON request body into v, rejecting unknown keys.
+func readBody(r *http.Request, v any) error {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	return yaml.UnmarshalWithOptions(body, v, yaml.DisallowUnknownField())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(marshalJSON(v), '\n'))
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func (s *server) listEntries(w http.ResponseWriter, r *http.Request) {
+	var entries []apiEntry
+	if !s.call(func() tea.Cmd {
+		entries = make([]apiEntry, 0, len(s.engine.queue.Entries))
+		for _, e := range s.engine.queue.Entries {
+			entries = append(entries, newAPIEntry(e))
+		}
+		return nil
+	}) {
+		writeError(w, http.StatusServiceUnavailable, errors.New("shutting down"))
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// addRequest is the body of POST /api/entries. Config is applied over the
+// named profile, like the flags of `mldy get -profile`.
+type addRequest struct {
+	URLs    []string        `yaml:"urls"`
+	Profile string          `yaml:"profile,omitempty"`
+	Config  yaml.RawMessage `yaml:"config,omitempty"`
+}
+
+func (s *server) addEntries(w http.ResponseWriter, r *http.Request) {
+	var req addRequest
+	if err := readBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	if len(req.URLs) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("no urls given"))
+		return
+	}
+
+	var status int
+	var reqErr error
+	if !s.call(func() tea.Cmd {
+		config, ok := s.engine.config.Profile(req.Profile)
+		if !ok {
+			status, reqErr = http.StatusBadRequest, fmt.Errorf("unknown profile %q", req.Profile)
+			return nil
+		}
+		if len(req.Config) > 0 {
+			if err := yaml.UnmarshalWithOptions(req.Config, &config, yaml.DisallowUnknownField()); err != nil {
+				status, reqErr = http.StatusBadRequest, fmt.Errorf("invalid config: %w", err)
+				return nil
+			}
+		}
+		if err := config.validate(); err != nil {
+			status, reqErr = http.StatusBadRequest, err
+			return nil
+		}
+		status = http.StatusAccepted
+		return s.engine.ResolveAll(req.URLs, config)
+	}) {
+		writeError(w, http.StatusServiceUnavailable, errors.New("shutting down"))
+		return
+	}
+	if reqErr != nil {
+		writeError(w, status, reqErr)
+		return
+	}
+	// The entries appear once resolved; the event stream announces them.
+	writeJSON(w, status, map[string]int{"resolving": len(req.URLs)})
+}
+
+// entryAction runs fn on the entry named in the path and answers with the
+// entry as it is afterwards. fn returns an error for entries in the wrong
+// state.
+func (s *server) entryAction(w http.ResponseWriter, r *http.Request, fn func(e *DownloadEntry) (tea.Cmd, error)) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no entry %q", r.PathValue("id")))
+		return
+	}
+
+	var status int
+	var result any
+	if !s.call(func() tea.Cmd {
+		e := s.engine.queue.GetByID(id)
+		if e == nil {
+			status, result = http.StatusNotFound, fmt.Errorf("no entry %d", id)
+			return nil
+		}
+		cmd, err := fn(e)
+		if err != nil {
+			status, result = http.StatusConflict, err
+			return nil
+		}
+		status = http.StatusOK
+		if e := s.engine.queue.GetByID(id); e != nil {
+			result = newAPIEntry(*e)
+		} else {
+			result = map[string]int{"removed": id}
+		}
+		return cmd
+	}) {
+		writeError(w, http.StatusServiceUnavailable, errors.New("shutting down"))
+		return
+	}
+	if err, ok := result.(error); ok {
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, status, result)
+}
+
+func (s *server) getEntry(w http.ResponseWriter, r *http.Request) {
+	s.entryAction(w, r, func(*DownloadEntry) (tea.Cmd, error) { return nil, nil })
+}
+
+// setEntryConfig replaces the override of an entry that is still queued.
+func (s *server) setEntryConfig(w http.ResponseWriter, r *http.Request) {
+	var config EntryConfig
+	if err := readBody(r, &config); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid config: %w", err))
+		return
+	}
+	if err := config.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.entryAction(w, r, func(e *DownloadEntry) (tea.Cmd, error) {
+		if e.Status != StatusQueued {
+			return nil, fmt.Errorf("entry %d has already started", e.ID)
+		}
+		s.engine.queue.Update(e.ID, func(e *DownloadEntry) { e.Config = config })
+		return nil, nil
+	})
+}
+
+func (s *server) pauseEntry(w http.ResponseWriter, r *http.Request) {
+	s.entryAction(w, r, func(e *DownloadEntry) (tea.Cmd, error) {
+		if e.Status != StatusDownloading {
+			return nil, fmt.Errorf("entry %d is %s, not downloading", e.ID, strings.ToLower(e.Status.String()))
+		}
+		return s.engine.Pause(e.ID), nil
+	})
+}
+
+func (s *server) resumeEntry(w http.ResponseWriter, r *http.Request) {
+	s.entryAction(w, r, func(e *DownloadEntry) (tea.Cmd, error) {
+		if e.Status != StatusPaused {
+			return nil, fmt.Errorf("entry %d is %s, not paused", e.ID, strings.ToLower(e.Status.String()))
+		}
+		return s.engine.Resume(e.ID), nil
+	})
+}
+
+// cancelEntry stops a download for good; a queued entry that never started
+// is simply removed, as on the Input screen.
+func (s *server) cancelEntry(w http.ResponseWriter, r *http.Request) {
+	s.entryAction(w, r, func(e *DownloadEntry) (tea.Cmd, error) {
+		switch e.Status {
+		case StatusQueued:
+			s.engine.queue.Remove(e.ID)
+			return nil, nil
+		case StatusDownloading, StatusPaused:
+			return s.engine.Cancel(e.ID), nil
+		}
+		return nil, fmt.Errorf("entry %d is %s and can't be canceled", e.ID, strings.ToLower(e.Status.String()))
+	})
+}
+
+// retryEntry queues a failed, canceled or skipped entry again; a skipped
+// one bypasses the download archive.
+func (s *server) retryEntry(w http.ResponseWriter, r *http.Request) {
+	s.entryAction(w, r, func(e *DownloadEntry) (tea.Cmd, error) {
+		switch e.Status {
+		case StatusFailed, StatusCanceled:
+			return tea.Batch(s.engine.Retry(e.ID), s.engine.Start()), nil
+		case StatusSkipped:
+			return tea.Batch(s.engine.Redownload(e.ID), s.engine.Start()), nil
+		}
+		return nil, fmt.Errorf("entry %d is %s and can't be retried", e.ID, strings.ToLower(e.Status.String()))
+	})
+}
+
+// start works through the queue if it isn't already, e.g. after everything
+// in it had finished and entries were re-queued.
+func (s *server) start(w http.ResponseWriter, r *http.Request) {
+	var status apiStatus
+	if !s.call(func() tea.Cmd {
+		cmd := s.engine.Start()
+		status = s.status()
+		return cmd
+	}) {
+		writeError(w, http.StatusServiceUnavailable, errors.New("shutting down"))
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *server) getConfig(w http.ResponseWriter, r *http.Request) {
+	var config Config
+	if !s.call(func() tea.Cmd {
+		config = s.engine.config
+		return nil
+	}) {
+		writeError(w, http.StatusServiceUnavailable, errors.New("shutting down"))
+		return
+	}
+	writeJSON(w, http.StatusOK, apiConfig(config))
+}
+
+// setConfig changes the keys given in the body, saves config.yaml and
+// applies it to downloads started from now on, then sends a "config" event.
+func (s *server) setConfig(w http.ResponseWriter, r *http.Request) {
+	var status int
+	var result any
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var keys map[string]any
+	if err := yaml.Unmarshal(body, &keys); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid config: %w", err))
+		return
+	}
+	for _, key := range []string{"cookies", "network"} {
+		if _, ok := keys[key]; ok {
+			writeError(w, http.StatusForbidden, fmt.Errorf("%s can only be changed in the daemon's config.yaml", key))
+			return
+		}
+	}
+	if !s.call(func() tea.Cmd {
+		// Decoding over a deep copy leaves the engine's maps alone when the
+		// body turns out invalid.
+		config, err := cloneConfig(s.engine.config)
+		if err != nil {
+			status, result = http.StatusInternalServerError, err
+			return nil
+		}
+		if err := yaml.UnmarshalWithOptions(body, &config, yaml.DisallowUnknownField()); err != nil {
+			status, result = http.StatusBadRequest, fmt.Errorf("invalid config: %w", err)
+			return nil
+		}
+		if err := config.validate(); err != nil {
+			status, result = http.StatusBadRequest, err
+			return nil
+		}
+		if err := saveConfig(config); err != nil {
+			status, result = http.StatusInternalServerError, fmt.Errorf("could not save config: %w", err)
+			return nil
+		}
+		s.engine.SetConfig(config)
+		status, result = http.StatusOK, apiConfig(config)
+		s.broadcast(sseEvent("config", marshalJSON(result)))
+		if s.engine.isRunning {
+			return s.engine.fillDownloadSlots()
+		}
+		return nil
+	}) {
+		writeError(w, http.StatusServiceUnavailable, errors.New("shutting down"))
+		return
+	}
+	if err, ok := result.(error); ok {
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, status, result)
+}
+
+// listHistory returns history records, newest first. q searches them like
+// the History screen, status filters by completed, failed or canceled and
+// limit caps the count (default 100, 0 for all).
+func (s *server) listHistory(w http.ResponseWriter, r *http.Request) {
+	filter := FilterAll
+	if name := r.URL.Query().Get("status"); name != "" {
+		for filter = FilterAll; filter < historyFilterCount; filter++ {
+			if strings.EqualFold(filter.String(), name) {
+				break
+			}
+		}
+		if filter == historyFilterCount {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("status must be completed, failed or canceled, not %q", name))
+			return
+		}
+	}
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("limit must be a number, not %q", v))
+			return
+		}
+		limit = n
+	}
+
+	var records []HistoryRecord
+	if !s.call(func() tea.Cmd {
+		records = s.engine.history.Search(r.URL.Query().Get("q"), filter)
+		return nil
+	}) {
+		writeError(w, http.StatusServiceUnavailable, errors.New("shutting down"))
+		return
+	}
+	if limit > 0 {
+		records = records[:min(limit, len(records))]
+	}
+	if records == nil {
+		records = []HistoryRecord{}
+	}
+	writeJSON(w, http.StatusOK, records)
+}
+
+// events streams server-sent events: first "entries" with the whole queue
+// and "status", then "entry" whenever an entry changes (including its
+// progress), "removed" when one leaves the queue, "status" when the daemon
+// starts or stops working and "config" when the config changes.
+func (s *server) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming not supported"))
+		return
+	}
+
+	ch := make(chan []byte, 256)
+	var snapshot []byte
+	if !s.call(func() tea.Cmd {
+		// Existing subscribers are already up to date, so the snapshot is
+		// what everyone has seen; without any, published may be stale.
+		entries := make([]apiEntry, 0, len(s.engine.queue.Entries))
+		s.published = make(map[int]string, len(s.engine.queue.Entries))
+		for _, e := range s.engine.queue.Entries {
+			entry := newAPIEntry(e)
+			entries = append(entries, entry)
+			s.published[e.ID] = string(marshalJSON(entry))
+		}
+		snapshot = append(sseEvent("entries", marshalJSON(entries)), sseEvent("status", marshalJSON(s.status()))...)
+		s.lastStatus = s.status()
+		s.subscribers[ch] = true
+		return nil
+	}) {
+		writeError(w, http.StatusServiceUnavailable, errors.New("shutting down"))
+		return
+	}
+	defer s.call(func() tea.Cmd {
+		if s.subscribers[ch] {
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+		return nil
+	})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	w.Write(snapshot)
+	flusher.Flush()
+
+	// Comments keep proxies from closing a quiet stream.
+	ping := time.NewTicker(30 * time.Second)
+	defer ping.Stop()
+	for {
+		select {
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+			w.Write(event)
+			flusher.Flush()
+		case <-ping.C:
+			io.WriteString(w, ": ping\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case <-s.quit:
+			return
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	zone "github.com/lrstanley/bubblezone/v2"
+)
+
+const settingsFormName = "settings"
+
+func newSettingsForm(cfg Config) configForm {
+	form := newConfigForm(fieldKind, fieldFormat, fieldAudioQuality, fieldVideoQuality, fieldOutputFolder,
+		fieldOutputTemplate, fieldPlaylistOutputTemplate, fieldRateLimit, fieldSkipDownloaded,
+		fieldSubtitleLangs, fieldAutoSubtitles, fieldSubtitleFormat, fieldEmbedSubtitles,
+		fieldEmbedChapters, fieldSplitChapters, fieldSponsorBlock, fieldSponsorBlockCategories, fieldMaxConcurrent,
+		fieldClipboardWatch, fieldClipboardDeny)
+	form.SetConfig(cfg)
+	return form
+}
+
+// updateSettings handles keys on the Settings screen, where the form always
+// has focus so every printable key is typed into it.
+func (m *Model) updateSettings(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter", "ctrl+s":
+		return m.saveSettings()
+	case "esc":
+		m.settingsForm.SetConfig(m.backend.Config())
+		m.settingsStatus = "Changes discarded"
+		m.settingsErr = nil
+		return m, nil
+	}
+	m.settingsStatus = ""
+	return m, m.settingsForm.Update(msg)
+}
+
+// saveSettings validates the form and hands the new config to the backend
+// so it applies to every download started from now on.
+func (m *Model) saveSettings() (tea.Model, tea.Cmd) {
+	cfg, err := m.settingsForm.Config(m.backend.Config())
+	if err != nil {
+		m.settingsErr = err
+		m.settingsStatus = ""
+		return m, nil
+	}
+	cmd, err := m.applyConfig(cfg)
+	if err != nil {
+		m.settingsErr = err
+		m.settingsStatus = ""
+		return m, nil
+	}
+	m.settingsErr = nil
+	m.settingsStatus = "✓ Saved"
+	return m, cmd
+}
+
+// applyConfig hands cfg to the backend, which saves it, and to the
+// Settings form.
+func (m *Model) applyConfig(cfg Config) (tea.Cmd, error) {
+	cmd, err := m.backend.SetConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	m.settingsForm.SetConfig(cfg)
+	return cmd, nil
+}
+
+// rateSteps are the limits the Downloads screen's +/- keys step through,
+// slowest first; the empty limit (unlimited) comes after the last one.
+var rateSteps = []RateLimit{"256K", "512K", "1M", "2M", "5M", "10M", "20M", "50M"}
+
+// stepRateLimit moves the global rate limit one step up (dir > 0) or down.
+// Running downloads keep their limit; the next ones started use the new one.
+func (m *Model) stepRateLimit(dir int) (tea.Model, tea.Cmd) {
+	current, _ := m.backend.Config().RateLimit.Bytes()
+	next := RateLimit("")
+	if dir > 0 {
+		// The first step above the current limit; unlimited stays unlimited.
+		if current > 0 {
+			for _, step := range rateSteps {
+				if b, _ := step.Bytes(); b > current {
+					next = step
+					break
+				}
+			}
+		}
+	} else {
+		// The last step below the current limit, or the slowest one.
+		next = rateSteps[0]
+		for _, step := range rateSteps {
+			if b, _ := step.Bytes(); current == 0 || b < current {
+				next = step
+			}
+		}
+	}
+
+	cfg := m.backend.Config()
+	cfg.RateLimit = next
+	cmd, err := m.applyConfig(cfg)
+	m.settingsErr = err
+	return m, cmd
+}
+
+func (m Model) renderSettingsScreen() string {
+	var s strings.Builder
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("170"))
+	faintStyle := lipgloss.NewStyle().Faint(true)
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("46"))
+	errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("208"))
+	btnStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("46")).
+		Border(lipgloss.RoundedBorder()).
+		Padding(0, 1)
+	resetBtnStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("241")).
+		Border(lipgloss.RoundedBorder()).
+		Padding(0, 1)
+
+	s.WriteString(titleStyle.Render("Settings"))
+	s.WriteString("\n\n")
+	s.WriteString(m.settingsForm.View(settingsFormName))
+	s.WriteString("\n")
+
+	if m.backend.Location() != "" {
+		s.WriteString(faintStyle.Render("  Cookies and network settings stay in the daemon's config.yaml"))
+		s.WriteString("\n")
+	} else if len(m.cookieChecks) == 0 {
+		s.WriteString(faintStyle.Render("  Cookies: none; add sites under cookies: in config.yaml"))
+		s.WriteString("\n")
+	} else {
+		s.WriteString("  Cookies:\n")
+	}
+	for _, r := range m.cookieChecks {
+		line := fmt.Sprintf("%s: %s", r.Site, r.Detail)
+		switch r.Status {
+		case cookiesOK:
+			s.WriteString("    " + successStyle.Render("✓ "+line))
+		case cookiesWarning:
+			s.WriteString("    " + warnStyle.Render("! "+line))
+		default:
+			s.WriteString("    " + errorStyle.Render("✗ "+line))
+		}
+		s.WriteString("\n")
+	}
+	s.WriteString("\n")
+
+	saveBtn := zone.Mark(zoneSettingsSave, btnStyle.Render("✓ Save"))
+	resetBtn := zone.Mark(zoneSettingsReset, resetBtnStyle.Render("↺ Discard"))
+	s.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, "  ", saveBtn, "  ", resetBtn))
+	s.WriteString("\n")
+
+	switch {
+	case m.settingsErr != nil:
+		s.WriteString(errorStyle.Render("  ✗ " + m.settingsErr.Error()))
+	case m.settingsStatus != "":
+		s.WriteString(successStyle.Render("  " + m.settingsStatus))
+	default:
+		s.WriteString(faintStyle.Render("  Saved to ~/.config/mldy/config.yaml; applies to downloads started afterwards."))
+	}
+
+	return s.String()
+}
@@ -11,7 +11,12 @@ import (
 	"strings"
 )
 
-func installYtDlp() error {
+// ytDlpWindowsURL is the latest yt-dlp release for Windows.
+const ytDlpWindowsURL = "https://github.com/yt-dlp/yt-dlp/releases/latest/download/yt-dlp.exe"
+
+// installYtDlp installs yt-dlp with the system's package manager, or on
+// Windows downloads it through network.
+func installYtDlp(network NetworkConfig) error {
 	var prefix []string
 	if rt.GOOS != "windows" && os.Geteuid() != 0 {
 		prefix = []string{"sudo"}
@@ -24,7 +29,11 @@ func installYtDlp() error {
 		installDir := filepath.Join(os.Getenv("LOCALAPPDATA"), "Microsoft", "WindowsApps")
 		destPath := filepath.Join(installDir, "yt-dlp.exe")
 
-		resp, err := http.Get("https://github.com/yt-dlp/yt-dlp/releases/latest/download/yt-dlp.exe")
+		client, err := network.For(ytDlpWindowsURL).httpClient()
+		if err != nil {
+			return err
+		}
+		resp, err := client.Get(ytDlpWindowsURL)
 		if err != nil {
 			return fmt.Errorf("failed to download yt-dlp: %w", err)
 		}
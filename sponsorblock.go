@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sponsorBlockArgs returns the yt-dlp flags for cfg's SponsorBlock
+// settings. yt-dlp appends the segments it found to segmentsFile (see
+// sponsorSegmentsFile), which readSponsorSegments counts afterwards.
+func sponsorBlockArgs(cfg Config, segmentsFile string) []string {
+	var flag string
+	switch cfg.SponsorBlock {
+	case SponsorBlockMark:
+		flag = "--sponsorblock-mark"
+	case SponsorBlockRemove:
+		flag = "--sponsorblock-remove"
+	default:
+		return nil
+	}
+	return []string{
+		flag, cfg.SponsorBlockCategories,
+		"--print-to-file", "after_move:%(sponsorblock_chapters)j", segmentsFile,
+	}
+}
+
+// sponsorSegmentsFile returns where a download with cfg has yt-dlp write
+// its SponsorBlock segments, or "" when SponsorBlock is off. The file lives
+// in a fresh private directory, since another user could plant a fixed name
+// in the shared temp dir or link it elsewhere; cleanup removes it.
+func sponsorSegmentsFile(cfg Config) (path string, cleanup func(), err error) {
+	if cfg.SponsorBlock != SponsorBlockMark && cfg.SponsorBlock != SponsorBlockRemove {
+		return "", func() {}, nil
+	}
+	dir, err := os.MkdirTemp("", "mldy-sponsorblock-")
+	if err != nil {
+		return "", nil, err
+	}
+	return filepath.Join(dir, "segments.json"), func() { os.RemoveAll(dir) }, nil
+}
+
+// readSponsorSegments counts the segments in a file written by
+// sponsorBlockArgs and how many seconds they cover. A missing or unreadable
+// file counts as no segments.
+func readSponsorSegments(path string) (segments int, seconds float64) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0
+	}
+	// One line per run; a video without segments prints "NA".
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	var chapters []struct {
+		Start float64 `json:"start_time"`
+		End   float64 `json:"end_time"`
+	}
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &chapters); err != nil {
+		return 0, 0
+	}
+	for _, c := range chapters {
+		seconds += c.End - c.Start
+	}
+	return len(chapters), seconds
+}
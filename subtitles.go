@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// subtitleArgs returns the yt-dlp flags for cfg's subtitle settings, or
+// nothing when no languages are configured.
+func subtitleArgs(cfg Config) []string {
+	if cfg.SubtitleLangs == "" {
+		return nil
+	}
+	args := []string{
+		"--write-subs",
+		"--sub-langs", cfg.SubtitleLangs,
+		"--sub-format", cfg.SubtitleFormat + "/best",
+		"--convert-subs", cfg.SubtitleFormat,
+	}
+	if cfg.AutoSubtitles {
+		args = append(args, "--write-auto-subs")
+	}
+	// Audio containers can't hold subtitles, so they stay separate files.
+	if cfg.EmbedSubtitles && cfg.EffectiveKind() == KindVideo {
+		args = append(args, "--embed-subs")
+	}
+	return args
+}
+
+// subtitleWritePrefix starts the line yt-dlp prints for every subtitle
+// file it writes, before conversion.
+const subtitleWritePrefix = "[info] Writing video subtitles to: "
+
+// collectSubtitles sorts the subtitle files yt-dlp wrote into the ones
+// left on disk and the languages of those gone from it: embedded (and then
+// deleted) when cfg embeds subtitles, missing otherwise, e.g. when the
+// conversion failed.
+func collectSubtitles(written []string, cfg Config) (files, embedded, missing []string) {
+	embeds := cfg.EmbedSubtitles && cfg.EffectiveKind() == KindVideo
+	for _, path := range written {
+		// "Title.en.vtt" becomes "Title.en.srt" after --convert-subs.
+		path = strings.TrimSuffix(path, filepath.Ext(path)) + "." + cfg.SubtitleFormat
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+			continue
+		}
+		lang := filepath.Ext(strings.TrimSuffix(path, filepath.Ext(path)))
+		lang = strings.TrimPrefix(lang, ".")
+		if embeds {
+			embedded = append(embedded, lang)
+		} else {
+			missing = append(missing, lang)
+		}
+	}
+	return files, embedded, missing
+}
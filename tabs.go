@@ -20,9 +20,21 @@ func (m Model) renderTabs() string {
 		return zone.Mark(zoneID, rendered)
 	}
 
-	return lipgloss.JoinHorizontal(lipgloss.Top,
+	tabs := lipgloss.JoinHorizontal(lipgloss.Top,
 		tab("Input/Queue", zoneTabInput, ScreenInput),
 		tab("Downloads", zoneTabDownload, ScreenDownload),
 		tab("History", zoneTabHistory, ScreenHistory),
+		tab("Settings", zoneTabSettings, ScreenSettings),
 	)
+
+	// Attached to a daemon: say which, and whether it's reachable.
+	location := m.backend.Location()
+	if location == "" {
+		return tabs
+	}
+	status := inactiveStyle.Render("⇄ " + location)
+	if err := m.backend.Err(); err != nil {
+		status = inactiveStyle.Foreground(lipgloss.Color("196")).Render("⚠ " + err.Error())
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, tabs, status)
 }